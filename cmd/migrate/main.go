@@ -0,0 +1,80 @@
+// Command migrate applies the versioned schema migrations to the temp, main
+// and backup databases.
+//
+//	go run ./cmd/migrate                  # apply pending migrations to all three
+//	go run ./cmd/migrate -db main         # only main_db
+//	go run ./cmd/migrate -db temp -down 1 # revert the last migration of temp_db
+//	go run ./cmd/migrate -status          # print the version of each database
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"cco-package/fetcher/config"
+	"cco-package/fetcher/migrations"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var databases = map[string]string{
+	"temp":   config.DbConnStr,
+	"main":   config.MainDbConnStr,
+	"backup": config.BackupDbConnStr,
+}
+
+func main() {
+	targets := flag.String("db", "temp,main,backup", "comma separated databases to migrate: temp, main, backup")
+	down := flag.Int("down", 0, "revert this many migrations instead of applying the pending ones")
+	status := flag.Bool("status", false, "print the schema version of each database and exit")
+	flag.Parse()
+
+	latest, err := migrations.Latest()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := false
+	for _, name := range strings.Split(*targets, ",") {
+		name = strings.TrimSpace(name)
+		if err := migrate(name, latest, *down, *status); err != nil {
+			log.Printf("%s_db: %v", name, err)
+			failed = true
+		}
+	}
+	if failed {
+		log.Fatal("Migration failed")
+	}
+}
+
+// migrate runs the requested action against one database.
+func migrate(name string, latest, down int, status bool) error {
+	dsn, ok := databases[name]
+	if !ok {
+		return fmt.Errorf("unknown database %q, use temp, main or backup", name)
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to connect: %v", err)
+	}
+
+	switch {
+	case status:
+	case down > 0:
+		err = migrations.Down(db, down)
+	default:
+		err = migrations.Up(db)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := migrations.Version(db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s_db is at schema version %d of %d\n", name, version, latest)
+	return nil
+}
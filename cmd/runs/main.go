@@ -0,0 +1,104 @@
+// Command runs prints the ingestion runs recorded in a database, and
+// optionally their steps, so a past run can be looked at after the fact.
+//
+//	go run ./cmd/runs                                    # runs of the last 7 days in temp_db
+//	go run ./cmd/runs -from 2026-10-13 -to 2026-10-14    # runs started on that day
+//	go run ./cmd/runs -from 2026-10-13 -steps            # with the step of every region
+//	go run ./cmd/runs -db main                           # runs recorded in main_db
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"cco-package/fetcher/config"
+	"cco-package/fetcher/history"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+var databases = map[string]string{
+	"temp":   config.DbConnStr,
+	"main":   config.MainDbConnStr,
+	"backup": config.BackupDbConnStr,
+}
+
+func main() {
+	target := flag.String("db", "temp", "database to read: temp, main or backup")
+	from := flag.String("from", time.Now().AddDate(0, 0, -7).Format(dateLayout), "first day to list, as YYYY-MM-DD")
+	to := flag.String("to", "", "day after the last day to list, as YYYY-MM-DD (default: tomorrow)")
+	steps := flag.Bool("steps", false, "also print the steps of every run")
+	flag.Parse()
+
+	dsn, ok := databases[*target]
+	if !ok {
+		log.Fatalf("Unknown database %q, use temp, main or backup", *target)
+	}
+	start, err := time.ParseInLocation(dateLayout, *from, time.Local)
+	if err != nil {
+		log.Fatalf("Invalid -from date: %v", err)
+	}
+	year, month, day := time.Now().Date()
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, time.Local)
+	if *to != "" {
+		if end, err = time.ParseInLocation(dateLayout, *to, time.Local); err != nil {
+			log.Fatalf("Invalid -to date: %v", err)
+		}
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to %s_db: %v", *target, err)
+	}
+	runs, err := history.RunsBetween(db, start, end)
+	if err != nil {
+		log.Fatalf("Failed to read runs: %v", err)
+	}
+	if len(runs) == 0 {
+		fmt.Printf("No runs started between %s and %s\n", start.Format(dateLayout), end.Format(dateLayout))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tPROVIDER\tSTATUS\tSTARTED\tDURATION\tINSERTED\tUPDATED\tDISABLED\tERROR")
+	for _, run := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\n", run.RunID, run.Provider, run.Status,
+			run.StartedAt.Format(time.DateTime), duration(run.StartedAt, run.FinishedAt),
+			run.RowsInserted, run.RowsUpdated, run.RowsDisabled, run.Error)
+		if *steps {
+			if err := printSteps(w, db, run.RunID); err != nil {
+				log.Fatalf("Failed to read steps of run %s: %v", run.RunID, err)
+			}
+		}
+	}
+	w.Flush()
+}
+
+// printSteps prints the steps of a run indented under it.
+func printSteps(w *tabwriter.Writer, db *gorm.DB, runID string) error {
+	steps, err := history.Steps(db, runID)
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		fmt.Fprintf(w, "  %s %s %s\t\t%s\t%s\t%s\t%d\t%d\t%d\t%s\n", step.Step, step.Service, step.Region, step.Status,
+			step.StartedAt.Format(time.DateTime), duration(step.StartedAt, step.FinishedAt),
+			step.RowsInserted, step.RowsUpdated, step.RowsDisabled, step.Error)
+	}
+	return nil
+}
+
+// duration formats how long a run or step took, or "running" if it has no
+// end time yet.
+func duration(start time.Time, finished *time.Time) string {
+	if finished == nil {
+		return "running"
+	}
+	return finished.Sub(start).Round(time.Second).String()
+}
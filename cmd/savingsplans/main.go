@@ -0,0 +1,20 @@
+// Command savingsplans ingests the AWS savings plan rates into temp_db on
+// their own, without the offer files of the other services. The savings plan
+// stage keeps its own manifest (saving_track.json), so an interrupted run is
+// resumed by running the command again.
+//
+//	go run ./cmd/savingsplans
+package main
+
+import (
+	"log"
+
+	"cco-package/fetcher"
+)
+
+func main() {
+	if err := fetcher.FetchSavingsPlans(); err != nil {
+		log.Fatalf("Savings plan ingestion failed: %v", err)
+	}
+	log.Println("Savings plan ingestion completed successfully.")
+}
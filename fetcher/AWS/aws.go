@@ -2,195 +2,370 @@ package AWS
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	
 	"cco-package/fetcher/AWS/models"
 	"cco-package/fetcher/AWS/saving"
 	"cco-package/fetcher/AWS/track"
 	"cco-package/fetcher/AWS/utils"
+	"cco-package/fetcher/history"
+	"cco-package/fetcher/regions"
+	"cco-package/fetcher/schema"
 	"cco-package/fetcher/config"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 	"cco-package/fetcher/AWS/basic"
 )
 
-func RunAWS() error {
+// RunAWS ingests the configured AWS services and then the savings plans. A
+// failing stage does not stop the other one; their errors are returned
+// together. Every region is recorded as a step of run, which may be nil.
+func RunAWS(run *history.Run) error {
+	provider, err := setupAWS()
+	if err != nil {
+		return err
+	}
+
+	offerErr := runOfferStage(provider, run)
+	if offerErr != nil {
+		log.Printf("AWS offer stage failed: %v", offerErr)
+	}
+	savingErr := runSavingPlanStage(provider, run)
+	if savingErr != nil {
+		log.Printf("AWS savings plan stage failed: %v", savingErr)
+	}
+	return errors.Join(offerErr, savingErr)
+}
+
+// setupAWS connects to the database, creates the price-list folder and
+// returns the AWS provider row. The tables are created by the migrations
+// package before the providers run.
+func setupAWS() (schema.Provider, error) {
+	var provider schema.Provider
+
 	// Step 1: Initialize the Database Connection (Using the global DB in config)
-	var err error
-	config.DB, err = gorm.Open(postgres.Open(config.DbConnStr), &gorm.Config{})
+	if err := config.EnsureDatabase(); err != nil {
+		return provider, fmt.Errorf("failed to connect to the database: %v", err)
+	}
+
+	// Step 2: Create the Folder for the PriceList
+	err := os.MkdirAll(config.PriceListPath, os.ModePerm)
 	if err != nil {
-		return fmt.Errorf("failed to connect to the database: %v", err)
+		return provider, fmt.Errorf("failed to create price-list directory: %v", err)
 	}
 
-	// Step 2: Auto-Migrate thae Tables (Including SavingPlan)
-	err = config.DB.AutoMigrate(&models.Provider{}, &models.Region{}, &models.SKU{}, &models.Price{}, &models.Term{}, &models.SavingPlan{})
+	// Step 3: Initialize Provider
+	provider = schema.Provider{ProviderName: "AWS"}
+	err = config.DB.FirstOrCreate(&provider, schema.Provider{ProviderName: "AWS"}).Error
 	if err != nil {
-		return fmt.Errorf("failed to auto-migrate tables: %v", err)
+		return provider, fmt.Errorf("failed to insert provider: %v", err)
 	}
+	return provider, nil
+}
 
-	// Step 3: Track File Handling
-	trackFile := "track.json"
-	if _, err := os.Stat(trackFile); os.IsNotExist(err) {
-		// If file doesn't exist, create an empty one
-		err = track.CreateEmptyTrackFile(trackFile)
+// resumeManifest loads the manifest of a stage and cleans up the regions
+// that were in flight when the previous run stopped, so they are loaded
+// again from scratch. Regions that finished are left alone. An old
+// single-region track file is read as an entry of legacyService.
+func resumeManifest(path, legacyService string, cleanup func(entry models.RegionState) error) (*track.Manifest, error) {
+	manifest, err := track.LoadManifest(path, legacyService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+
+	for _, entry := range manifest.Interrupted() {
+		log.Printf("Region %s/%s was interrupted while %s. Cleaning data...", entry.ServiceCode, entry.RegionName, entry.State)
+		if err := cleanup(entry); err != nil {
+			return nil, fmt.Errorf("failed to remove region data: %v", err)
+		}
+		if err := manifest.Failed(entry.ServiceCode, entry.RegionName, errors.New("interrupted by a previous run")); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// runStageJobs marks the jobs as pending in the manifest and runs them on
+// the region worker pool, recording failures in the manifest.
+func runStageJobs(manifest *track.Manifest, jobs []regionJob, process func(regionJob) error) error {
+	for _, job := range jobs {
+		if err := manifest.Pending(job.ServiceCode, job.RegionCode, job.Version); err != nil {
+			return err
+		}
+	}
+
+	return runJobs(jobs, func(job regionJob) error {
+		err := process(job)
 		if err != nil {
-			return fmt.Errorf("failed to create empty track file: %v", err)
+			if mErr := manifest.Failed(job.ServiceCode, job.RegionCode, err); mErr != nil {
+				log.Printf("Failed to record failure of %s: %v", job.Name(), mErr)
+			}
 		}
+		return err
+	})
+}
+
+// runOfferStage ingests the offer file of every configured service in every region.
+func runOfferStage(provider schema.Provider, run *history.Run) error {
+	// The old single-region track file only tracked EC2
+	manifest, err := resumeManifest("track.json", "AmazonEC2", func(entry models.RegionState) error {
+		return track.RemoveServiceRegionByCode(config.DB, entry.ServiceCode, entry.RegionName)
+	})
+	if err != nil {
+		return err
 	}
 
-	// Step 4: Read the current state from track.json
-	state, err := track.ReadTrackFile("track.json")
+	// Download the offer index listing every AWS service
+	var offerIndex models.OfferIndex
+	err = downloadJSON(config.OfferIndexURL, filepath.Join(config.PriceListPath, "index.json"), &offerIndex)
 	if err != nil {
-		return fmt.Errorf("failed to read track file: %v", err)
+		return fmt.Errorf("failed to read offer index: %v", err)
 	}
-	
-	if state.State != "processed" {
-		fmt.Println("Previous region is not processed. Cleaning data...")
-		err = track.RemoveRegionData(config.DB, state.RegionName)
+
+	// Build the region jobs of every configured service
+	var jobs []regionJob
+	regionIDs := make(map[string]uint)
+	for _, serviceCode := range config.AWSServices() {
+		offer, ok := offerIndex.Offers[serviceCode]
+		if !ok {
+			log.Printf("Service %s is not in the offer index, skipping", serviceCode)
+			continue
+		}
+
+		service := models.Service{ServiceCode: serviceCode, ServiceName: serviceCode, ProviderID: provider.ProviderID}
+		err = config.DB.FirstOrCreate(&service, models.Service{ServiceCode: serviceCode}).Error
 		if err != nil {
-			return fmt.Errorf("failed to remove region data: %v", err)
+			return fmt.Errorf("failed to insert service %s into DB: %v", serviceCode, err)
+		}
+
+		var regionData models.RegionIndex
+		regionFilePath := filepath.Join(config.PriceListPath, serviceCode+"_region_index.json")
+		err = downloadJSON(config.BaseURL+offer.CurrentRegionIndexUrl, regionFilePath, &regionData)
+		if err != nil {
+			return fmt.Errorf("failed to read region index of %s: %v", serviceCode, err)
+		}
+
+		for _, region := range regionData.Regions {
+			// Insert the Region data into DB before the workers start, since
+			// several services share the same region
+			regionID, err := ensureRegion(regionIDs, region.RegionCode, provider.ProviderID)
+			if err != nil {
+				return err
+			}
+			version, err := track.VersionFromURL(serviceCode, region.CurrentVersionUrl)
+			if err != nil {
+				return err
+			}
+
+			jobs = append(jobs, regionJob{
+				ServiceCode:     serviceCode,
+				ServiceID:       service.ServiceID,
+				RegionCode:      region.RegionCode,
+				RegionID:        regionID,
+				VersionURL:      region.CurrentVersionUrl,
+				Version:         version,
+				PublicationDate: regionData.PublicationDate,
+			})
 		}
 	}
-	
 
-	// Step 5: Create the Folder for the PriceList
-	err = os.MkdirAll(config.PriceListPath, os.ModePerm)
+	// Process the regions in parallel
+	err = runStageJobs(manifest, jobs, func(job regionJob) error {
+		return processRegion(job, manifest, run)
+	})
+
+	// Drop the cached offer files of services and regions no longer listed
+	pruneCache(jobs, offerCacheName, func(name string) bool {
+		for serviceCode := range offerIndex.Offers {
+			if strings.HasPrefix(name, serviceCode+"-") {
+				return true
+			}
+		}
+		return false
+	})
+	return err
+}
+
+// ensureRegion returns the ID of the region, inserting it if it is new.
+// regionIDs caches the regions already seen in this run.
+func ensureRegion(regionIDs map[string]uint, regionCode string, providerID uint) (uint, error) {
+	if regionID, ok := regionIDs[regionCode]; ok {
+		return regionID, nil
+	}
+
+	regionEntry := schema.Region{
+		RegionCode: regionCode,
+		ProviderID: providerID,
+	}
+	err := config.DB.FirstOrCreate(&regionEntry, schema.Region{ProviderID: providerID, RegionCode: regionCode}).Error
 	if err != nil {
-		return fmt.Errorf("failed to create price-list directory: %v", err)
+		return 0, fmt.Errorf("failed to insert region data into DB: %v", err)
+	}
+
+	// Fill the catalogue fields from the region code. The offer file refines
+	// them with the location attributes of its products.
+	if regionEntry.LocationKey == "" {
+		info := regions.AWS(regionCode, "", "")
+		if err := config.DB.Model(&regionEntry).Updates(info.Columns()).Error; err != nil {
+			return 0, fmt.Errorf("failed to update region %s: %v", regionCode, err)
+		}
 	}
+	regionIDs[regionCode] = regionEntry.RegionID
+	return regionEntry.RegionID, nil
+}
+
+// runJobs runs the jobs on the region worker pool and joins the errors of
+// the regions that failed.
+func runJobs(jobs []regionJob, process func(regionJob) error) error {
+	workers := config.RegionWorkers()
+	log.Printf("Processing %d regions with %d workers", len(jobs), workers)
+	results := runRegionPool(jobs, workers, process)
 
-	// Step 6: Initialize Provider and Service
-	provider := models.Provider{ProviderName: "AWS"}
-	config.DB.FirstOrCreate(&provider, models.Provider{ProviderName: "AWS"})
+	var failed []error
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", result.Name, result.Err))
+		}
+	}
+	if len(failed) > 0 {
+		log.Printf("Processing complete, %d of %d regions failed.", len(failed), len(jobs))
+		return errors.Join(failed...)
+	}
 
-	// Step 7: Download the region_index.json file (Basic Plan)
-	regionFilePath := filepath.Join(config.PriceListPath, "region_index.json")
-	err = utils.DownloadFile(config.RegionURL, regionFilePath)
+	log.Println("Processing complete.")
+	return nil
+}
+
+// processRegion downloads and ingests the offer file of one service in one
+// region. Files whose version was already ingested are skipped; otherwise
+// the old rows are removed before the new version is loaded. Every download
+// goes to its own temp file, so regions can run in parallel without
+// overwriting each other.
+func processRegion(job regionJob, manifest *track.Manifest, run *history.Run) (err error) {
+	step := run.StartStep("offer", job.ServiceCode, job.RegionCode)
+	var rows models.RowCounts
+	skipped := false
+	defer func() {
+		if skipped {
+			step.Skip()
+		} else {
+			step.Finish(historyRows(rows), err)
+		}
+	}()
+
+	ingested, err := track.IsVersionIngested(config.DB, job.ServiceCode, job.RegionCode, job.Version)
 	if err != nil {
-		return fmt.Errorf("failed to download region: %v", err)
+		return err
+	}
+	if ingested {
+		log.Printf("Skipping region %s, version %s already ingested", job.Name(), job.Version)
+		skipped = true
+		return manifest.UpToDate(job.ServiceCode, job.RegionCode, job.Version)
 	}
 
-	// Step 8: Download the saving_region_index.json file (Saving Plan)
-	savingRegionFilePath := filepath.Join(config.PriceListPath, "saving_region_index.json")
-	err = utils.DownloadFile(config.SavingRegionURL, savingRegionFilePath)
+	log.Printf("Processing region: %s (version %s)", job.Name(), job.Version)
+	if err := manifest.Start(job.ServiceCode, job.RegionCode, job.Version); err != nil {
+		return err
+	}
+	removed, err := track.RemoveServiceRegionData(config.DB, job.ServiceID, job.RegionID)
 	if err != nil {
-		return fmt.Errorf("failed to download saving region: %v", err)
+		return fmt.Errorf("failed to remove previous version: %v", err)
 	}
 
-	// Step 9: Open and Process the region_index.json file (Basic Plan)
-	regionFile, err := os.Open(regionFilePath)
+	// Download and process the current version file for the region (Basic Plan)
+	currentVersionURL := config.BaseURL + job.VersionURL
+	err = downloadAndProcess(currentVersionURL, offerCacheName(job), func(path string) error {
+		if err := manifest.Parsing(job.ServiceCode, job.RegionCode); err != nil {
+			return err
+		}
+		var err error
+		rows, err = basic.ProcessCurrentVersionFile(config.DB, path, job.RegionID, job.ServiceID)
+		return err
+	})
+	rows.Removed = removed
 	if err != nil {
-		return fmt.Errorf("failed to open region file: %v", err)
+		return fmt.Errorf("failed to process current version file: %v", err)
 	}
-	defer regionFile.Close()
 
-	var regionData struct {
-		Regions map[string]struct {
-			RegionCode        string `json:"regionCode"`
-			CurrentVersionUrl string `json:"currentVersionUrl"`
-		} `json:"regions"`
+	if err := track.SaveVersion(config.DB, job.ServiceCode, job.RegionCode, job.Version, job.PublicationDate); err != nil {
+		return err
 	}
-	err = json.NewDecoder(regionFile).Decode(&regionData)
-	if err != nil {
-		return fmt.Errorf("failed to decode region index file: %v", err)
+
+	// The reloaded SKUs have new IDs, relink the savings plans that discount them
+	if err := saving.LinkDiscountedSkus(config.DB, job.RegionID); err != nil {
+		return err
 	}
 
-	// Step 10: Open and Process the saving_region_index.json file (Saving Plan)
-	savingRegionFile, err := os.Open(savingRegionFilePath)
-	if err != nil {
-		return fmt.Errorf("failed to open saving region file: %v", err)
+	return manifest.Loaded(job.ServiceCode, job.RegionCode, rows)
+}
+
+// historyRows converts the row counts of a region to run history counts.
+func historyRows(rows models.RowCounts) history.Rows {
+	written := rows.SKUs + rows.Prices + rows.Terms + rows.SavingPlans
+	return history.Rows{
+		Inserted: written - rows.Updated,
+		Updated:  rows.Updated,
+		Disabled: rows.Removed,
 	}
-	defer savingRegionFile.Close()
+}
 
-	// Updated structure for saving region data
-	var savingRegionData struct {
-		Regions []struct {
-			RegionCode string `json:"regionCode"`
-			VersionUrl string `json:"versionUrl"`
-		} `json:"regions"`
+// downloadJSON downloads url to path and decodes it into v.
+func downloadJSON(url, path string, v interface{}) error {
+	if err := utils.DownloadFile(url, path); err != nil {
+		return fmt.Errorf("failed to download %s: %v", url, err)
 	}
 
-	// Decode the saving region index file
-	err = json.NewDecoder(savingRegionFile).Decode(&savingRegionData)
+	file, err := os.Open(path)
 	if err != nil {
-		return fmt.Errorf("failed to decode saving region index file: %v", err)
+		return fmt.Errorf("failed to open %s: %v", path, err)
 	}
+	defer file.Close()
 
-	// Process each region for Basic Plan and Saving Plan
-	for regionCode, region := range regionData.Regions {
-		log.Printf("Processing region: %s", region.RegionCode)
-		track.UpdateTrackFile(trackFile, region.RegionCode, "processing")
-		// Insert the Region data into DB (for Basic Plan)
-		regionEntry := models.Region{
-			RegionCode: region.RegionCode,
-			ProviderID: provider.ProviderID,
-		}
-		err = config.DB.FirstOrCreate(&regionEntry, models.Region{RegionCode: region.RegionCode}).Error
-		if err != nil {
-			return fmt.Errorf("failed to insert region data into DB: %v", err)
-		}
+	if err := json.NewDecoder(file).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return nil
+}
 
-		// Download the current version file for the region (Basic Plan)
-		currentVersionURL := config.BaseURL + region.CurrentVersionUrl
-		currentVersionFile := filepath.Join(config.PriceListPath, region.RegionCode+".json")
-		err = utils.DownloadFile(currentVersionURL, currentVersionFile)
-		if err != nil {
-			log.Printf("Failed to download %s: %v", currentVersionURL, err)
-			continue
-		}
+// offerCacheName is the name of the cached offer file of a region job.
+func offerCacheName(job regionJob) string {
+	return job.ServiceCode + "-" + job.RegionCode
+}
 
-		// Process the Basic Plan current version file
-		
-		err = basic.ProcessCurrentVersionFile(config.DB, currentVersionFile, regionEntry.RegionID)
-		if err != nil {
-			return fmt.Errorf("failed to process current version file: %v", err)
-		}
+// pruneCache removes the cached files of a stage that belong to none of its
+// jobs. match selects the files of the stage. A failure only costs disk
+// space, so it is logged.
+func pruneCache(jobs []regionJob, cacheName func(regionJob) string, match func(name string) bool) {
+	keep := make(map[string]bool, len(jobs))
+	for _, job := range jobs {
+		keep[cacheName(job)+".json"] = true
+	}
+	removed, err := utils.PruneCache(config.PriceListPath, keep, match)
+	if err != nil {
+		log.Printf("Failed to prune the price-list cache: %v", err)
+	} else if removed > 0 {
+		log.Printf("Removed %d superseded files from the price-list cache", removed)
+	}
+}
 
-		// Remove the current version file (Basic Plan)
-		err = os.Remove(currentVersionFile)
-		if err != nil {
-			log.Printf("Failed to delete file %s: %v", currentVersionFile, err)
-		} else {
-			log.Printf("Successfully deleted file: %s", currentVersionFile)
-		}
+// downloadAndProcess downloads url into the price-list cache and runs
+// process on it. Every service/region pair has its own file, so parallel
+// workers never share one, and the file is kept so the next download of an
+// unchanged offer is answered with 304 Not Modified.
+func downloadAndProcess(url, cacheName string, process func(path string) error) error {
+	path := filepath.Join(config.PriceListPath, cacheName+".json")
 
-		// Process the corresponding region in the saving region index (Saving Plan)
-		for _, savingRegion := range savingRegionData.Regions {
-			if savingRegion.RegionCode == regionCode {
-			
-
-				// Download the saving plan version file for the region
-				savingVersionURL := config.BaseURL + savingRegion.VersionUrl
-				savingVersionFile := filepath.Join(config.PriceListPath, savingRegion.RegionCode+".json")
-				err = utils.DownloadFile(savingVersionURL, savingVersionFile)
-				if err != nil {
-					log.Printf("Failed to download saving version file %s: %v", savingVersionURL, err)
-					continue
-				}
-
-				// Process the Saving Plan version file
-				err = saving.ProcessVersionFile(config.DB, savingVersionFile, regionEntry.RegionID)
-				if err != nil {
-					return fmt.Errorf("failed to process saving version file: %v", err)
-				}
-
-				// Remove the saving plan version file
-				err = os.Remove(savingVersionFile)
-				if err != nil {
-					log.Printf("Failed to delete saving version file %s: %v", savingVersionFile, err)
-				} else {
-					track.UpdateTrackFile(trackFile, savingRegion.RegionCode, "processed")
-					log.Printf("Successfully deleted saving version file: %s", savingVersionFile)
-				}
-			}
-		}
+	changed, err := utils.DefaultDownloader().Download(url, path)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %v", url, err)
 	}
-
-	log.Println("Processing complete.")
-	return nil
+	if !changed {
+		log.Printf("Reusing unchanged file: %s", path)
+	}
+	return process(path)
 }
 
@@ -1,180 +1,248 @@
 package basic
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
-	"gorm.io/gorm"
 	"cco-package/fetcher/AWS/models"
 	"cco-package/fetcher/AWS/utils"
-	"cco-package/fetcher/AWS/convertData"
+	"cco-package/fetcher/convertData"
+	"cco-package/fetcher/quality"
+	"cco-package/fetcher/regions"
+	"cco-package/fetcher/schema"
+	"gorm.io/gorm"
 )
 
-func ProcessCurrentVersionFile(db *gorm.DB, filepath string, regionID uint) error {
+// ProcessCurrentVersionFile streams a region offer file into the DB. Products
+// and terms are handed to a bulkWriter as they are read, which writes them in
+// batches instead of one statement per row.
+// It returns the number of rows written.
+func ProcessCurrentVersionFile(db *gorm.DB, filepath string, regionID, serviceID uint) (models.RowCounts, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
-		return fmt.Errorf("failed to open current version file: %v", err)
+		return models.RowCounts{}, fmt.Errorf("failed to open current version file: %v", err)
 	}
 	defer file.Close()
 
-	var data models.PricingData
-
-	err = json.NewDecoder(file).Decode(&data)
+	regionCode, providerID, err := lookupRegion(db, regionID)
 	if err != nil {
-		return fmt.Errorf("failed to decode current version file: %v", err)
+		return models.RowCounts{}, err
 	}
 
-	// Convert map to slice and call function to process products (SKUs)
-	productsSlice := mapToSlice(data.Products)
-	err = processProducts(db, productsSlice, regionID)
-	if err != nil {
-		return fmt.Errorf("failed to process products: %v", err)
-	}
-
-	// Call function to process terms
-	err = processTerms(db, data.Terms["OnDemand"])
+	writer := newBulkWriter(db, providerID, regionID)
+	var location, locationType string
+	err = streamOfferFile(file, offerHandler{
+		Product: func(product models.Product) error {
+			// Remember where the region is; transfer products only have a from/to location
+			if location == "" && product.Attributes["regionCode"] == regionCode {
+				location, locationType = product.Attributes["location"], product.Attributes["locationType"]
+			}
+			if err := processProduct(writer, product, regionID, serviceID, regionCode, providerID); err != nil {
+				return fmt.Errorf("failed to process products: %v", err)
+			}
+			return nil
+		},
+		Term: func(termType, skuCode string, term models.TermDetails) error {
+			if err := processTerm(writer, termType, skuCode, term); err != nil {
+				return fmt.Errorf("failed to process %s terms: %v", termType, err)
+			}
+			return nil
+		},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to process on-demand terms: %v", err)
+		return writer.Rows(), fmt.Errorf("failed to decode current version file: %v", err)
 	}
 
-	err = processTerms(db, data.Terms["Reserved"])
-	if err != nil {
-		return fmt.Errorf("failed to process reserved terms: %v", err)
+	if err := writer.Flush(); err != nil {
+		return writer.Rows(), err
 	}
-
-	return nil
+	return writer.Rows(), updateRegionLocation(db, regionID, regionCode, location, locationType)
 }
 
-// Helper function to convert map to slice
-func mapToSlice(productsMap map[string]models.Product) []models.Product {
-	productsSlice := make([]models.Product, 0, len(productsMap))
-	for _, product := range productsMap {
-		productsSlice = append(productsSlice, product)
+// Function to store the region's location attributes and catalogue entry
+func updateRegionLocation(db *gorm.DB, regionID uint, regionCode, location, locationType string) error {
+	if location == "" {
+		return nil
+	}
+	columns := regions.AWS(regionCode, location, locationType).Columns()
+	columns["region_name"] = location
+	if err := db.Model(&schema.Region{}).Where("region_id = ?", regionID).Updates(columns).Error; err != nil {
+		return fmt.Errorf("failed to update location of region %s: %v", regionCode, err)
 	}
-	return productsSlice
+	return nil
 }
 
-// Function to process and insert products (SKUs) into the DB
-func processProducts(db *gorm.DB, products []models.Product, regionID uint) error {
+// Function to fetch the region code and AWS provider ID for a region
+func lookupRegion(db *gorm.DB, regionID uint) (string, uint, error) {
 	var regionCode string
 	if err := db.Table("regions").Select("region_code").Where("region_id = ?", regionID).Scan(&regionCode).Error; err != nil || regionCode == "" {
-		return fmt.Errorf("failed to fetch region_code for regionID %d: %v", regionID, err)
+		return "", 0, fmt.Errorf("failed to fetch region_code for regionID %d: %v", regionID, err)
 	}
 	fmt.Printf("Fetched regionCode: %s\n", regionCode)
 
 	// Fetch the Provider ID for AWS
 	var providerID uint
 	if err := db.Table("providers").Select("provider_id").Where("provider_name = ?", "AWS").Scan(&providerID).Error; err != nil || providerID == 0 {
-		return fmt.Errorf("failed to fetch provider ID for AWS: %v", err)
+		return "", 0, fmt.Errorf("failed to fetch provider ID for AWS: %v", err)
 	}
 	fmt.Printf("Fetched ProviderID: %d\n", providerID)
 
-	for _, product := range products {
-		// Check and parse VCPU, default to 0 if missing
-		vcpu, err := strconv.Atoi(utils.DefaultIfEmpty(product.Attributes["vcpu"], "0"))
-		if err != nil {
-			return fmt.Errorf("failed to convert vcpu for SKU %s: %v", product.SKU, err)
-		}
-		if product.ProductFamily == "Compute Instance" || product.ProductFamily == "Compute Instance (bare metal)" {
-			product.ProductFamily = "Compute"
-		}
+	return regionCode, providerID, nil
+}
 
-		// Convert and normalize fields
-		networkData := convertData.ConvertNetwork(product.Attributes["networkPerformance"])
-		memoryData := convertData.ConvertMemory(product.Attributes["memory"])
-
-		// Extract additional attributes
-		armSkuName := product.Attributes["armSkuName"]
-		physicalProcessor := product.Attributes["physicalProcessor"]
-		maxThroughput := product.Attributes["dedicatedEbsThroughput"]
-		enhancedNetworking := product.Attributes["enhancedNetworkingSupported"]
-		gpu := product.Attributes["gpuMemory"]
-		maxIOPS := product.Attributes["maxIopsvolume"]
-
-		// Create SKU record
-		sku := models.SKU{
-			SKUCode:             product.SKU,
-			RegionID:            regionID,
-			ProviderID:          providerID,
-			RegionCode:          regionCode,
-			ArmSkuName:          armSkuName,
-			InstanceSKU:         product.Attributes["instancesku"],
-			ProductFamily:       product.ProductFamily,
-			VCPU:                vcpu,
-			Type:                product.Attributes["usagetype"],
-			OperatingSystem:     product.Attributes["operatingSystem"],
-			InstanceType:        product.Attributes["instanceType"],
-			Storage:             product.Attributes["storage"],
-			Network:             networkData,
-			CpuArchitecture:     product.Attributes["processorArchitecture"],
-			Memory:              memoryData,
-			PhysicalProcessor:   physicalProcessor,
-			MaxThroughput:       maxThroughput,
-			EnhancedNetworking:  enhancedNetworking,
-			GPU:                 gpu,
-			MaxIOPS:             maxIOPS,
-		}
+// Function to process a product (SKU) and queue it for insertion
+func processProduct(writer *bulkWriter, product models.Product, regionID, serviceID uint, regionCode string, providerID uint) error {
+	// Check and parse VCPU, default to 0 if missing
+	vcpu, err := strconv.Atoi(utils.DefaultIfEmpty(product.Attributes["vcpu"], "0"))
+	if err != nil {
+		return fmt.Errorf("failed to convert vcpu for SKU %s: %v", product.SKU, err)
+	}
+	if product.ProductFamily == "Compute Instance" || product.ProductFamily == "Compute Instance (bare metal)" {
+		product.ProductFamily = "Compute"
+	}
 
-		// Insert SKU (check if it exists, create if not)
-		if err := db.FirstOrCreate(&sku, models.SKU{SKUCode: sku.SKUCode}).Error; err != nil {
-			return fmt.Errorf("failed to insert SKU %s: %v", product.SKU, err)
-		}
+	// Parse the typed hardware columns, keeping the raw text alongside.
+	// Values that cannot be parsed are stored as NULL and recorded.
+	network := product.Attributes["networkPerformance"]
+	networkBaseline, networkBurst, err := convertData.ParseNetworkMbps(network)
+	if err != nil {
+		writer.AddIssue(quality.Issue("AWS", "skus", product.SKU, "network_burst_mbps", network, err))
+	}
+	storage := product.Attributes["storage"]
+	storageInfo, err := convertData.ParseStorage(storage)
+	if err != nil {
+		writer.AddIssue(quality.Issue("AWS", "skus", product.SKU, "total_local_gb", storage, err))
+	}
+	memory := product.Attributes["memory"]
+	memoryGiB, err := convertData.ParseMemoryGiB(memory)
+	if err != nil {
+		writer.AddIssue(quality.Issue("AWS", "skus", product.SKU, "memory_gib", memory, err))
 	}
 
-	return nil
+	// Extract additional attributes
+	armSkuName := product.Attributes["armSkuName"]
+	physicalProcessor := product.Attributes["physicalProcessor"]
+	maxThroughput := product.Attributes["dedicatedEbsThroughput"]
+	enhancedNetworking := product.Attributes["enhancedNetworkingSupported"]
+	gpu := product.Attributes["gpuMemory"]
+	maxIOPS := product.Attributes["maxIopsvolume"]
+
+	// Create SKU record
+	sku := schema.SKU{
+		SKUCode:             product.SKU,
+		RegionID:            regionID,
+		ProviderID:          providerID,
+		ServiceID:           serviceID,
+		RegionCode:          regionCode,
+		ArmSkuName:          armSkuName,
+		InstanceSKU:         product.Attributes["instancesku"],
+		ProductFamily:       product.ProductFamily,
+		VCPU:                vcpu,
+		Type:                product.Attributes["usagetype"],
+		OperatingSystem:     product.Attributes["operatingSystem"],
+		InstanceType:        product.Attributes["instanceType"],
+		Storage:             storage,
+		Network:             network,
+		NetworkBaselineMbps: networkBaseline,
+		NetworkBurstMbps:    networkBurst,
+		CpuArchitecture:     product.Attributes["processorArchitecture"],
+		Memory:              memory,
+		MemoryGiB:           memoryGiB,
+		PhysicalProcessor:   physicalProcessor,
+		MaxThroughput:       maxThroughput,
+		EnhancedNetworking:  enhancedNetworking,
+		GPU:                 gpu,
+		MaxIOPS:             maxIOPS,
+	}
+
+	if storageInfo != nil {
+		sku.EBSOnly = &storageInfo.EBSOnly
+		sku.DiskCount = storageInfo.DiskCount
+		sku.DiskSizeGB = storageInfo.DiskSizeGB
+		sku.TotalLocalGB = storageInfo.TotalLocalGB
+		sku.StorageMediaType = storageInfo.MediaType
+	}
+
+	// Queue SKU (upserted on provider, region and sku_code when the batch is flushed)
+	return writer.AddSKU(sku)
 }
 
-func processTerms(db *gorm.DB, terms map[string]map[string]models.TermDetails) error {
-	for skuCode, termData := range terms {
-		for _, termDetails := range termData {
-			// Fetch the SKU_ID for the given SKU code
-			var skuID uint
-			if err := db.Table("skus").Select("id").Where("sku_code = ?", skuCode).Scan(&skuID).Error; err != nil {
-				return fmt.Errorf("failed to find SKU_ID for SKU %s: %v", skuCode, err)
+// Function to process a single term of a SKU and queue its prices for insertion
+func processTerm(writer *bulkWriter, termType, skuCode string, termDetails models.TermDetails) error {
+	// Check if TermAttributes have non-empty values
+	leaseContractLength := termDetails.TermAttributes.LeaseContractLength
+	purchaseOption := termDetails.TermAttributes.PurchaseOption
+	offeringClass := termDetails.TermAttributes.OfferingClass
+	hasTermAttributes := leaseContractLength != "" || purchaseOption != "" || offeringClass != ""
+
+	leaseContractYears, err := convertData.ParseYears(leaseContractLength)
+	if err != nil {
+		writer.AddIssue(quality.Issue("AWS", "terms", skuCode, "lease_contract_years", leaseContractLength, err))
+	}
+
+	// Extract the PriceDimension data
+	for _, priceDetails := range termDetails.PriceDimensions {
+		// Parse the tier boundaries of the price dimension
+		beginRange, endRange, err := parseRange(priceDetails.BeginRange, priceDetails.EndRange)
+		if err != nil {
+			return fmt.Errorf("invalid range for rate %s: %v", priceDetails.RateCode, err)
+		}
+
+		// Store one price row per currency (USD, or CNY in the China partition)
+		for currency, pricePerUnit := range priceDetails.PricePerUnit {
+			if pricePerUnit == "" {
+				continue
 			}
 
-			// Extract the PriceDimension data
-			for _, priceDetails := range termDetails.PriceDimensions {
-				pricePerUnit := priceDetails.PricePerUnit["USD"]
-
-				// Create a term entry in Price
-				termEntry := models.Price{
-					SKU_ID:        skuID,
-					Description :  priceDetails.Description,
-					EffectiveDate: termDetails.EffectiveDate,
-					Unit:          priceDetails.Unit,
-					PricePerUnit:  pricePerUnit,
-				}
+			// Create a term entry in Price
+			termEntry := schema.Price{
+				TermType:      termType,
+				RateCode:      priceDetails.RateCode,
+				Description:   priceDetails.Description,
+				EffectiveDate: termDetails.EffectiveDate,
+				Unit:          priceDetails.Unit,
+				PricePerUnit:  pricePerUnit,
+				Currency:      currency,
+				BeginRange:    beginRange,
+				EndRange:      endRange,
+				AppliesTo:     strings.Join(priceDetails.AppliesTo, ","),
+			}
 
-				// Insert the term entry into the database
-				if err := db.Create(&termEntry).Error; err != nil {
-					return fmt.Errorf("failed to insert term for SKU %s: %v", skuCode, err)
+			// Attach term attributes only if there are non-empty values
+			var termAttributes *schema.Term
+			if hasTermAttributes {
+				termAttributes = &schema.Term{
+					LeaseContractLength: leaseContractLength,
+					LeaseContractYears:  leaseContractYears,
+					PurchaseOption:      purchaseOption,
+					OfferingClass:       offeringClass,
 				}
+			}
 
-				// Check if TermAttributes have non-empty values
-				leaseContractLength := termDetails.TermAttributes.LeaseContractLength
-				purchaseOption := termDetails.TermAttributes.PurchaseOption
-				offeringClass := termDetails.TermAttributes.OfferingClass
-
-				if leaseContractLength != "" || purchaseOption != "" || offeringClass != "" {
-					// Insert term attributes only if there are non-empty values
-					termAttributes := models.Term{
-						SKU_ID:              skuID,
-						LeaseContractLength: convertData.ConvertYear(leaseContractLength),
-						PurchaseOption:      purchaseOption,
-						OfferingClass:       offeringClass,
-						PriceID:             termEntry.PriceID,
-					}
-
-					// Insert term attributes into the database
-					if err := db.Create(&termAttributes).Error; err != nil {
-						return fmt.Errorf("failed to insert termAttributes for SKU %s: %v", skuCode, err)
-					}
-				}
+			// SKU_ID and PriceID are filled in by the writer
+			if err := writer.AddPrice(skuCode, termEntry, termAttributes); err != nil {
+				return fmt.Errorf("failed to insert term for SKU %s: %v", skuCode, err)
 			}
 		}
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// Helper function to parse the beginRange/endRange of a price dimension.
+// An empty or "Inf" end range is returned as nil, meaning unbounded.
+func parseRange(begin, end string) (float64, *float64, error) {
+	beginRange, err := strconv.ParseFloat(utils.DefaultIfEmpty(begin, "0"), 64)
+	if err != nil {
+		return 0, nil, err
+	}
+	if end == "" || strings.EqualFold(end, "Inf") {
+		return beginRange, nil, nil
+	}
+	endRange, err := strconv.ParseFloat(end, 64)
+	if err != nil {
+		return 0, nil, err
+	}
+	return beginRange, &endRange, nil
+}
@@ -0,0 +1,173 @@
+package basic
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"cco-package/fetcher/AWS/models"
+)
+
+// offerHandler receives the entries of an offer file one at a time.
+type offerHandler struct {
+	Product func(product models.Product) error
+	Term    func(termType, skuCode string, term models.TermDetails) error
+}
+
+// streamOfferFile walks an AWS offer file token by token and hands every
+// product and every OnDemand/Reserved term to the handler as soon as it has
+// been decoded, so only one entry is held in memory regardless of file size.
+// AWS writes the products section before the terms section, which lets the
+// term handler rely on the products already being stored.
+func streamOfferFile(r io.Reader, h offerHandler) error {
+	dec := json.NewDecoder(bufio.NewReaderSize(r, 1<<20))
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := readKey(dec)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "products":
+			err = streamProducts(dec, h.Product)
+		case "terms":
+			err = streamTerms(dec, h.Term)
+		default:
+			err = skipValue(dec)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %q section: %v", key, err)
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// streamProducts decodes the "products" object entry by entry.
+func streamProducts(dec *json.Decoder, handle func(models.Product) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		skuCode, err := readKey(dec)
+		if err != nil {
+			return err
+		}
+
+		var product models.Product
+		if err := dec.Decode(&product); err != nil {
+			return fmt.Errorf("failed to decode product %s: %v", skuCode, err)
+		}
+		if err := handle(product); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// streamTerms decodes terms.OnDemand and terms.Reserved entry by entry and
+// skips any other term type.
+func streamTerms(dec *json.Decoder, handle func(string, string, models.TermDetails) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		termType, err := readKey(dec)
+		if err != nil {
+			return err
+		}
+		if termType != "OnDemand" && termType != "Reserved" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// terms.<type>.<skuCode>.<offerTermCode> = TermDetails
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for dec.More() {
+			skuCode, err := readKey(dec)
+			if err != nil {
+				return err
+			}
+			if err := expectDelim(dec, '{'); err != nil {
+				return err
+			}
+			for dec.More() {
+				offerTermCode, err := readKey(dec)
+				if err != nil {
+					return err
+				}
+
+				var term models.TermDetails
+				if err := dec.Decode(&term); err != nil {
+					return fmt.Errorf("failed to decode %s term %s for SKU %s: %v", termType, offerTermCode, skuCode, err)
+				}
+				if err := handle(termType, skuCode, term); err != nil {
+					return err
+				}
+			}
+			if err := expectDelim(dec, '}'); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// readKey reads the next object key from the decoder.
+func readKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("expected object key, got %v", tok)
+	}
+	return key, nil
+}
+
+// expectDelim reads the next token and checks that it is the given delimiter.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+// skipValue discards the next value, however deeply nested, without
+// materialising it.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
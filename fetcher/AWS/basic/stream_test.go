@@ -0,0 +1,224 @@
+package basic
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"cco-package/fetcher/AWS/models"
+)
+
+// offer is a small offer file with two products, an OnDemand price with two
+// tiers and a Reserved term with an upfront fee and an hourly rate.
+const offer = `{
+  "formatVersion": "v1.0",
+  "disclaimer": "This pricing list is for informational purposes only.",
+  "offerCode": "AmazonEC2",
+  "version": "20250512175616",
+  "products": {
+    "SKU1": {
+      "sku": "SKU1",
+      "productFamily": "Compute Instance",
+      "attributes": {"instanceType": "m5.large", "memory": "8 GiB", "vcpu": "2"}
+    },
+    "SKU2": {
+      "sku": "SKU2",
+      "productFamily": "Storage",
+      "attributes": {"volumeType": "General Purpose"}
+    }
+  },
+  "terms": {
+    "OnDemand": {
+      "SKU1": {
+        "SKU1.JRTCKXETXF": {
+          "offerTermCode": "JRTCKXETXF",
+          "sku": "SKU1",
+          "effectiveDate": "2025-05-01T00:00:00Z",
+          "priceDimensions": {
+            "SKU1.JRTCKXETXF.6YS6EN2CT7": {
+              "rateCode": "SKU1.JRTCKXETXF.6YS6EN2CT7",
+              "description": "$0.096 per On Demand Linux m5.large Instance Hour",
+              "beginRange": "0",
+              "endRange": "10",
+              "unit": "Hrs",
+              "pricePerUnit": {"USD": "0.0960000000"},
+              "appliesTo": []
+            },
+            "SKU1.JRTCKXETXF.7YS6EN2CT7": {
+              "rateCode": "SKU1.JRTCKXETXF.7YS6EN2CT7",
+              "beginRange": "10",
+              "endRange": "Inf",
+              "unit": "Hrs",
+              "pricePerUnit": {"USD": "0.0900000000"},
+              "appliesTo": []
+            }
+          },
+          "termAttributes": {}
+        }
+      }
+    },
+    "Reserved": {
+      "SKU1": {
+        "SKU1.38NPMPTW36": {
+          "offerTermCode": "38NPMPTW36",
+          "sku": "SKU1",
+          "effectiveDate": "2025-05-01T00:00:00Z",
+          "priceDimensions": {
+            "SKU1.38NPMPTW36.2TG2D8R56U": {
+              "rateCode": "SKU1.38NPMPTW36.2TG2D8R56U",
+              "unit": "Quantity",
+              "pricePerUnit": {"USD": "450"},
+              "appliesTo": []
+            },
+            "SKU1.38NPMPTW36.6YS6EN2CT7": {
+              "rateCode": "SKU1.38NPMPTW36.6YS6EN2CT7",
+              "unit": "Hrs",
+              "pricePerUnit": {"USD": "0.0510000000"},
+              "appliesTo": []
+            }
+          },
+          "termAttributes": {
+            "LeaseContractLength": "1yr",
+            "OfferingClass": "standard",
+            "PurchaseOption": "Partial Upfront"
+          }
+        }
+      }
+    }
+  }
+}`
+
+// event is one call of the offer handler.
+type event struct {
+	Kind   string // "product" or "<termType> term"
+	SKU    string
+	Prices []string // Rate code=price of each price dimension, sorted
+	Attrs  string   // Product attributes or term attributes
+}
+
+func collect(input string) ([]event, error) {
+	var events []event
+	err := streamOfferFile(strings.NewReader(input), offerHandler{
+		Product: func(product models.Product) error {
+			keys := make([]string, 0, len(product.Attributes))
+			for key, value := range product.Attributes {
+				keys = append(keys, key+"="+value)
+			}
+			sort.Strings(keys)
+			events = append(events, event{Kind: "product", SKU: product.SKU, Attrs: product.ProductFamily + " " + strings.Join(keys, ",")})
+			return nil
+		},
+		Term: func(termType, skuCode string, term models.TermDetails) error {
+			var prices []string
+			for _, dimension := range term.PriceDimensions {
+				prices = append(prices, dimension.RateCode+"="+dimension.PricePerUnit["USD"])
+			}
+			sort.Strings(prices)
+			attributes := term.TermAttributes
+			events = append(events, event{
+				Kind:   termType + " term",
+				SKU:    skuCode,
+				Prices: prices,
+				Attrs:  strings.TrimSpace(attributes.LeaseContractLength + " " + attributes.OfferingClass + " " + attributes.PurchaseOption),
+			})
+			return nil
+		},
+	})
+	return events, err
+}
+
+func TestStreamOfferFile(t *testing.T) {
+	products := []event{
+		{Kind: "product", SKU: "SKU1", Attrs: "Compute Instance instanceType=m5.large,memory=8 GiB,vcpu=2"},
+		{Kind: "product", SKU: "SKU2", Attrs: "Storage volumeType=General Purpose"},
+	}
+	onDemand := event{Kind: "OnDemand term", SKU: "SKU1", Prices: []string{
+		"SKU1.JRTCKXETXF.6YS6EN2CT7=0.0960000000",
+		"SKU1.JRTCKXETXF.7YS6EN2CT7=0.0900000000",
+	}}
+	reserved := event{Kind: "Reserved term", SKU: "SKU1", Prices: []string{
+		"SKU1.38NPMPTW36.2TG2D8R56U=450",
+		"SKU1.38NPMPTW36.6YS6EN2CT7=0.0510000000",
+	}, Attrs: "1yr standard Partial Upfront"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []event
+	}{
+		{
+			name:  "products before terms",
+			input: offer,
+			want:  append(append([]event{}, products...), onDemand, reserved),
+		},
+		{
+			name: "unknown keys at every level are skipped",
+			input: strings.Replace(strings.Replace(offer,
+				`"products": {`, `"attributesList": {"a": [1, {"b": [2, 3]}], "c": null}, "products": {`, 1),
+				`"OnDemand": {`, `"Spot": {"SKU1": {"x": {"priceDimensions": {"y": {"pricePerUnit": {"USD": "1"}}}}}}, "OnDemand": {`, 1),
+			want: append(append([]event{}, products...), onDemand, reserved),
+		},
+		{
+			name:  "terms only",
+			input: `{"terms": {"OnDemand": {}, "Reserved": {"SKU9": {}}}}`,
+			want:  nil,
+		},
+		{
+			name:  "empty offer",
+			input: `{}`,
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collect(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamOfferFileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not an object", `[]`},
+		{"truncated products", `{"products": {"SKU1": {"sku": "SKU1"`},
+		{"truncated terms", offer[:strings.Index(offer, `"Reserved"`)]},
+		{"product of the wrong type", `{"products": {"SKU1": []}}`},
+		{"term of the wrong type", `{"terms": {"OnDemand": {"SKU1": {"SKU1.X": "x"}}}}`},
+		{"term type that is not an object", `{"terms": {"OnDemand": []}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := collect(tt.input); err == nil {
+				t.Error("streamOfferFile() accepted a malformed offer")
+			}
+		})
+	}
+}
+
+func TestStreamOfferFileStopsOnHandlerError(t *testing.T) {
+	stop := errors.New("stop")
+	terms := 0
+	err := streamOfferFile(strings.NewReader(offer), offerHandler{
+		Product: func(models.Product) error { return nil },
+		Term: func(string, string, models.TermDetails) error {
+			terms++
+			return stop
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), stop.Error()) {
+		t.Errorf("streamOfferFile() error = %v, want %v", err, stop)
+	}
+	if terms != 1 {
+		t.Errorf("term handler called %d times after failing, want 1", terms)
+	}
+}
@@ -0,0 +1,211 @@
+package basic
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"cco-package/fetcher/AWS/models"
+	"cco-package/fetcher/quality"
+	"cco-package/fetcher/schema"
+)
+
+// writerBatchSize is the number of rows sent per multi-row INSERT. It keeps
+// the widest row (SKU) well below Postgres' 65535 bind parameter limit.
+const writerBatchSize = 1000
+
+// skuUpdateColumns are refreshed when a SKU already exists in the region.
+var skuUpdateColumns = []string{
+	"service_id", "region_code", "arm_sku_name", "instance_sku",
+	"product_family", "vcpu", "cpu_architecture", "instance_type", "storage",
+	"ebs_only", "disk_count", "disk_size_gb", "total_local_gb", "storage_media_type",
+	"network", "network_baseline_mbps", "network_burst_mbps", "operating_system",
+	"type", "memory", "memory_gib", "physical_processor",
+	"max_throughput", "enhanced_networking", "gpu", "max_iops",
+}
+
+// errSKUNotFound is the reason recorded for prices whose SKU code is not
+// among the products read before them.
+var errSKUNotFound = errors.New("no product with this SKU code precedes its terms")
+
+// pricedTerm is a price row together with the optional term row that points at it.
+type pricedTerm struct {
+	price schema.Price
+	term  *schema.Term
+}
+
+// bulkWriter buffers the SKU, price and term rows of an offer file and
+// writes them in batches. SKU IDs are kept in memory while the products are
+// written, so terms are resolved without a query per term. All SKUs of a
+// writer belong to one provider and region, so they are keyed by SKU code.
+type bulkWriter struct {
+	db         *gorm.DB
+	providerID uint
+	regionID   uint
+	skus       []schema.SKU
+	prices     []pricedTerm
+	issues     []quality.DataQualityIssue
+	skuIDs     map[string]uint
+	unknown    map[string]bool // SKU codes whose prices were skipped
+	rows       models.RowCounts
+}
+
+func newBulkWriter(db *gorm.DB, providerID, regionID uint) *bulkWriter {
+	return &bulkWriter{
+		db:         db,
+		providerID: providerID,
+		regionID:   regionID,
+		skus:       make([]schema.SKU, 0, writerBatchSize),
+		prices:     make([]pricedTerm, 0, writerBatchSize),
+		skuIDs:     make(map[string]uint),
+		unknown:    make(map[string]bool),
+	}
+}
+
+// AddSKU buffers a SKU and flushes the buffer once it is full.
+func (w *bulkWriter) AddSKU(sku schema.SKU) error {
+	w.skus = append(w.skus, sku)
+	if len(w.skus) >= writerBatchSize {
+		return w.flushSKUs()
+	}
+	return nil
+}
+
+// AddPrice buffers a price of the given SKU code and its optional term.
+// Prices of SKUs that were not part of the product pass are skipped, counted
+// and recorded as a data quality issue once per SKU code.
+func (w *bulkWriter) AddPrice(skuCode string, price schema.Price, term *schema.Term) error {
+	// Products come before terms, so make sure every SKU has its ID.
+	if len(w.skus) > 0 {
+		if err := w.flushSKUs(); err != nil {
+			return err
+		}
+	}
+
+	skuID, ok := w.skuIDs[skuCode]
+	if !ok {
+		w.rows.Skipped++
+		if !w.unknown[skuCode] {
+			w.unknown[skuCode] = true
+			w.AddIssue(quality.Issue("AWS", "prices", skuCode, "sku_id", skuCode, errSKUNotFound))
+		}
+		return nil
+	}
+
+	price.SKU_ID = skuID
+	if term != nil {
+		term.SKU_ID = skuID
+	}
+	w.prices = append(w.prices, pricedTerm{price: price, term: term})
+	if len(w.prices) >= writerBatchSize {
+		return w.flushPrices()
+	}
+	return nil
+}
+
+// AddIssue buffers a value that could not be parsed. Issues are rare, so
+// they are only written by Flush.
+func (w *bulkWriter) AddIssue(issue quality.DataQualityIssue) {
+	w.issues = append(w.issues, issue)
+}
+
+// Rows returns the number of rows written so far.
+func (w *bulkWriter) Rows() models.RowCounts {
+	return w.rows
+}
+
+// Flush writes everything that is still buffered.
+func (w *bulkWriter) Flush() error {
+	if err := w.flushSKUs(); err != nil {
+		return err
+	}
+	if err := w.flushPrices(); err != nil {
+		return err
+	}
+	if err := quality.Record(w.db, w.issues...); err != nil {
+		return err
+	}
+	if len(w.issues) > 0 {
+		log.Printf("Recorded %d values that could not be parsed", len(w.issues))
+		w.issues = w.issues[:0]
+	}
+	if w.rows.Skipped > 0 {
+		log.Printf("Skipped %d prices of %d SKU codes without a product", w.rows.Skipped, len(w.unknown))
+	}
+	return nil
+}
+
+// flushSKUs upserts the buffered SKUs on (provider_id, region_id, sku_code)
+// and records their IDs.
+func (w *bulkWriter) flushSKUs() error {
+	if len(w.skus) == 0 {
+		return nil
+	}
+
+	// Count the SKUs that already exist, so the run history can tell
+	// inserted rows from updated ones
+	codes := make([]string, len(w.skus))
+	for i, sku := range w.skus {
+		codes[i] = sku.SKUCode
+	}
+	var existing int64
+	if err := w.db.Model(&schema.SKU{}).Where("provider_id = ? AND region_id = ? AND sku_code IN ?", w.providerID, w.regionID, codes).Count(&existing).Error; err != nil {
+		return fmt.Errorf("failed to count existing SKUs: %v", err)
+	}
+
+	updates := clause.AssignmentColumns(skuUpdateColumns)
+	updates = append(updates, clause.Assignment{Column: clause.Column{Name: "modified_date"}, Value: gorm.Expr("current_timestamp")})
+
+	err := w.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider_id"}, {Name: "region_id"}, {Name: "sku_code"}},
+		DoUpdates: updates,
+	}).Create(&w.skus).Error
+	if err != nil {
+		return fmt.Errorf("failed to insert %d SKUs: %v", len(w.skus), err)
+	}
+
+	for _, sku := range w.skus {
+		w.skuIDs[sku.SKUCode] = sku.ID
+	}
+	w.rows.SKUs += int64(len(w.skus))
+	w.rows.Updated += existing
+	w.skus = w.skus[:0]
+	return nil
+}
+
+// flushPrices inserts the buffered prices, then the terms that reference them.
+func (w *bulkWriter) flushPrices() error {
+	if len(w.prices) == 0 {
+		return nil
+	}
+
+	prices := make([]schema.Price, len(w.prices))
+	for i, p := range w.prices {
+		prices[i] = p.price
+	}
+	if err := w.db.Create(&prices).Error; err != nil {
+		return fmt.Errorf("failed to insert %d prices: %v", len(prices), err)
+	}
+
+	var terms []schema.Term
+	for i, p := range w.prices {
+		if p.term == nil {
+			continue
+		}
+		p.term.PriceID = prices[i].PriceID
+		terms = append(terms, *p.term)
+	}
+	if len(terms) > 0 {
+		if err := w.db.Create(&terms).Error; err != nil {
+			return fmt.Errorf("failed to insert %d terms: %v", len(terms), err)
+		}
+	}
+
+	w.rows.Prices += int64(len(prices))
+	w.rows.Terms += int64(len(terms))
+	w.prices = w.prices[:0]
+	return nil
+}
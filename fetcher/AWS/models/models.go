@@ -4,94 +4,79 @@ import (
 	"time"
 )
 
-	type RegionState struct {
-		RegionName string `json:"region_name"`
-		State      string `json:"state"`
-	}
+// RegionState is the manifest entry of one service in one region
+type RegionState struct {
+	ServiceCode   string     `json:"service_code"`
+	RegionName    string     `json:"region_name"`
+	State         string     `json:"state"`
+	SourceVersion string     `json:"source_version,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	Rows          RowCounts  `json:"rows"`
+	LastError     string     `json:"last_error,omitempty"`
+}
 
-type Provider struct {
-	ProviderID   uint   `gorm:"primaryKey"`
-	ProviderName string `gorm:"unique"`
-	CreatedDate  time.Time `gorm:"default:current_timestamp"`
-	ModifiedDate time.Time `gorm:"default:current_timestamp"`
-	DisableFlag  bool      `gorm:"default:false"`
+// RowCounts is the number of rows an offer file was loaded into
+type RowCounts struct {
+	SKUs        int64 `json:"skus"`
+	Prices      int64 `json:"prices"`
+	Terms       int64 `json:"terms"`
+	SavingPlans int64 `json:"saving_plans"`
+	Updated     int64 `json:"updated"` // Rows above that already existed and were updated
+	Removed     int64 `json:"removed"` // Rows of the previous version that were removed
+	Skipped     int64 `json:"skipped"` // Prices dropped because their SKU was not in the file
 }
 
-type Region struct {
-	RegionID   uint   `gorm:"primaryKey"`
-	RegionCode string `gorm:"unique"`
-	RegionName string `gorm:"column:region_name"`
-	ProviderID uint   `gorm:"not null;constraint:OnDelete:CASCADE;"` // Foreign key with cascade delete
+type Service struct {
+	ServiceID    uint   `gorm:"primaryKey"`
+	ProviderID   uint   `gorm:"not null;constraint:OnDelete:CASCADE;"` // Foreign key with cascade delete
+	ServiceCode  string `gorm:"unique"`
+	ServiceName  string
 	CreatedDate  time.Time `gorm:"default:current_timestamp"`
 	ModifiedDate time.Time `gorm:"default:current_timestamp"`
 	DisableFlag  bool      `gorm:"default:false"`
 }
 
-type SKU struct {
+// OfferVersion records the last offer file version ingested for a service in a region
+type OfferVersion struct {
 	ID              uint   `gorm:"primaryKey"`
-	RegionID        uint   `gorm:"not null;constraint:OnDelete:CASCADE;"` // Foreign key with cascade delete
-	ProviderID      uint   `gorm:"not null"`
-	RegionCode      string `gorm:"not null"`
-	SKUCode         string `gorm:"unique"`
-	ArmSkuName      string `gorm:"column:arm_sku_name"`
-	InstanceSKU     string
-	ProductFamily   string
-	VCPU            int
-	CpuArchitecture string
-	InstanceType    string
-	Storage         string
-	Network         string
-	OperatingSystem string
-	Type 		    string 
-	Memory          string
-	PhysicalProcessor          string `gorm:"column:physical_processor"` // "physicalProcessor"
-	MaxThroughput              string `gorm:"column:max_throughput"`     // "dedicatedEbsThroughput"
-	EnhancedNetworking         string `gorm:"column:enhanced_networking"` // "enhancedNetworkingSupported"
-	GPU                        string `gorm:"column:gpu"` // "gpuMemory"
-	MaxIOPS                    string `gorm:"column:max_iops"` // "maxIopsvolume"
-	CreatedDate  time.Time `gorm:"default:current_timestamp"`
-	ModifiedDate time.Time `gorm:"default:current_timestamp"`
-	DisableFlag  bool      `gorm:"default:false"`
+	ServiceCode     string `gorm:"not null;uniqueIndex:idx_offer_version_region"`
+	RegionCode      string `gorm:"not null;uniqueIndex:idx_offer_version_region"`
+	Version         string `gorm:"not null"`
+	PublicationDate string
+	CreatedDate     time.Time `gorm:"default:current_timestamp"`
+	ModifiedDate    time.Time `gorm:"default:current_timestamp"`
 }
 
-type Price struct {
-	PriceID       uint      `gorm:"primaryKey;autoIncrement"`
-	SKU_ID        uint      `gorm:"not null;constraint:OnDelete:CASCADE;"` // Foreign key with cascade delete
-	EffectiveDate string    `gorm:"type:varchar(255)"`
-	Unit          string    `gorm:"type:varchar(50)"`
-	Description string `gorm:"type:varchar(255)"`
-	PricePerUnit  string    `gorm:"type:varchar(50)"`
-	CreatedDate  time.Time `gorm:"default:current_timestamp"`
-	ModifiedDate time.Time `gorm:"default:current_timestamp"`
-	DisableFlag  bool      `gorm:"default:false"`
+// OfferIndex is the top-level offers/v1.0/aws/index.json file
+type OfferIndex struct {
+	Offers map[string]OfferEntry `json:"offers"`
 }
 
-type Term struct {
-	OfferTermID         int    `gorm:"primaryKey;autoIncrement"`
-	SKU_ID              uint   `gorm:"not null;constraint:OnDelete:CASCADE;"` // Foreign key with cascade delete
-	PriceID             uint   `gorm:"not null;constraint:OnDelete:CASCADE;"` // Foreign key with cascade delete
-	LeaseContractLength string `gorm:"size:255"`
-	PurchaseOption      string `gorm:"size:255"`
-	OfferingClass       string `gorm:"size:255"`
-	CreatedDate  time.Time `gorm:"default:current_timestamp"`
-	ModifiedDate time.Time `gorm:"default:current_timestamp"`
-	DisableFlag  bool      `gorm:"default:false"`
+type OfferEntry struct {
+	OfferCode             string `json:"offerCode"`
+	VersionIndexUrl       string `json:"versionIndexUrl"`
+	CurrentVersionUrl     string `json:"currentVersionUrl"`
+	CurrentRegionIndexUrl string `json:"currentRegionIndexUrl"`
+}
+
+// RegionIndex is the current/region_index.json file of an offer
+type RegionIndex struct {
+	PublicationDate string `json:"publicationDate"`
+	Regions         map[string]struct {
+		RegionCode        string `json:"regionCode"`
+		CurrentVersionUrl string `json:"currentVersionUrl"`
+	} `json:"regions"`
 }
 
-type SavingPlan struct {
-	ID                  uint      `gorm:"primaryKey"`
-	DiscountedSku       string
-	Sku                 string
-	LeaseContractLength int
-	DiscountedRate      string
-	RegionID            uint      `gorm:"not null;constraint:OnDelete:CASCADE;"` // Foreign key with cascade delete
-	RegionCode          string    `gorm:"not null"`
-	ProviderID          uint      `gorm:"not null;constraint:OnDelete:CASCADE;"` // Foreign key to providers
-	DiscountedInstanceType string `gorm:"not null"` // Added this field
-	Unit                string    `gorm:"not null"`
-	CreatedDate         time.Time `gorm:"default:current_timestamp"`
-	ModifiedDate        time.Time `gorm:"default:current_timestamp"`
-	DisableFlag         bool      `gorm:"default:false"`
+// SavingRegionIndex is the region_index.json file of the savings plan offer
+type SavingRegionIndex struct {
+	PublicationDate string `json:"publicationDate"`
+	Regions         []struct {
+		RegionCode string `json:"regionCode"`
+		VersionUrl string `json:"versionUrl"`
+	} `json:"regions"`
 }
 
 // ! Iska usage kya hai pata nhi filhal
@@ -157,7 +142,19 @@ type RateDetails struct {
 	DiscountedInstanceType string `json:"discountedInstanceType"` // Added DiscountedInstanceType
 }
 
+// SavingProduct is an entry of the products section of a savings plan file,
+// describing the plan itself
+type SavingProduct struct {
+	Sku           string            `json:"sku"`
+	ProductFamily string            `json:"productFamily"`
+	ServiceCode   string            `json:"serviceCode"`
+	UsageType     string            `json:"usageType"`
+	Operation     string            `json:"operation"`
+	Attributes    map[string]string `json:"attributes"`
+}
+
 type SavingData struct {
+	Products  []SavingProduct `json:"products"`
 	TermsPlan struct {
 		SavingsPlan []SavingTermDetails `json:"savingsPlan"`
 	} `json:"terms"`
@@ -0,0 +1,74 @@
+package AWS
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// regionJob describes the offer file of a single service in a single region.
+type regionJob struct {
+	ServiceCode     string
+	ServiceID       uint
+	RegionCode      string
+	RegionID        uint
+	VersionURL      string
+	Version         string
+	PublicationDate string
+}
+
+// Name identifies the job in logs and errors.
+func (j regionJob) Name() string {
+	return j.ServiceCode + "/" + j.RegionCode
+}
+
+// regionResult is the outcome of one regionJob.
+type regionResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// runRegionPool runs process for every job on at most workers goroutines.
+// A failing region is recorded in its result and does not stop the others.
+func runRegionPool(jobs []regionJob, workers int, process func(regionJob) error) []regionResult {
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	jobChan := make(chan regionJob)
+	resultChan := make(chan regionResult, len(jobs))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobChan {
+				start := time.Now()
+				err := process(job)
+				resultChan <- regionResult{Name: job.Name(), Err: err, Duration: time.Since(start)}
+			}
+		}()
+	}
+
+	go func() {
+		for _, job := range jobs {
+			jobChan <- job
+		}
+		close(jobChan)
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	results := make([]regionResult, 0, len(jobs))
+	for result := range resultChan {
+		results = append(results, result)
+		if result.Err != nil {
+			log.Printf("[%d/%d] Region %s failed after %v: %v", len(results), len(jobs), result.Name, result.Duration.Round(time.Second), result.Err)
+		} else {
+			log.Printf("[%d/%d] Region %s processed in %v", len(results), len(jobs), result.Name, result.Duration.Round(time.Second))
+		}
+	}
+	return results
+}
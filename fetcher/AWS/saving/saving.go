@@ -8,13 +8,18 @@ import (
 
 	"gorm.io/gorm"
 	"cco-package/fetcher/AWS/models"
+	"cco-package/fetcher/schema"
 )
 
-func ProcessVersionFile(db *gorm.DB, filepath string, regionID uint) error {
+// ProcessVersionFile loads the savings plan rates of a region and returns the
+// number of rows written.
+func ProcessVersionFile(db *gorm.DB, filepath string, regionID uint) (models.RowCounts, error) {
+	var rows models.RowCounts
+
 	// Open the JSON file
 	file, err := os.Open(filepath)
 	if err != nil {
-		return fmt.Errorf("failed to open version file: %v", err)
+		return rows, fmt.Errorf("failed to open version file: %v", err)
 	}
 	defer file.Close()
 
@@ -22,44 +27,87 @@ func ProcessVersionFile(db *gorm.DB, filepath string, regionID uint) error {
 	var data models.SavingData
 	err = json.NewDecoder(file).Decode(&data)
 	if err != nil {
-		return fmt.Errorf("failed to decode version file: %v", err)
+		return rows, fmt.Errorf("failed to decode version file: %v", err)
 	}
 
 	// Fetch RegionCode from regions table
 	var regionCode string
 	if err := db.Table("regions").Select("region_code").Where("region_id = ?", regionID).Scan(&regionCode).Error; err != nil || regionCode == "" {
-		return fmt.Errorf("failed to fetch region_code for regionID %d: %v", regionID, err)
+		return rows, fmt.Errorf("failed to fetch region_code for regionID %d: %v", regionID, err)
 	}
 	log.Printf("Fetched regionCode: %s\n", regionCode)
 
 	// Fetch ProviderID (assuming a single provider for the region)
 	var providerID uint
 	if err := db.Table("providers").Select("provider_id").Where("provider_name = ?", "AWS").Scan(&providerID).Error; err != nil || providerID == 0 {
-		return fmt.Errorf("failed to fetch provider_id for AWS: %v", err)
+		return rows, fmt.Errorf("failed to fetch provider_id for AWS: %v", err)
 	}
 	log.Printf("Fetched providerID: %d\n", providerID)
 
+	// Index the plan definitions by their SKU
+	plans := make(map[string]models.SavingProduct, len(data.Products))
+	for _, product := range data.Products {
+		plans[product.Sku] = product
+	}
+
 	// Process the terms section
+	var savingPlans []schema.SavingPlan
 	for _, term := range data.TermsPlan.SavingsPlan {
+		plan, ok := plans[term.Sku]
+		if !ok {
+			log.Printf("No plan definition found for SavingPlan SKU %s", term.Sku)
+		}
+
 		for _, rate := range term.Rates {
-			savingPlan := models.SavingPlan{
+			savingPlans = append(savingPlans, schema.SavingPlan{
 				Sku:                    term.Sku,
+				PlanType:               plan.ProductFamily,
+				PurchaseOption:         plan.Attributes["purchaseOption"],
+				PurchaseTerm:           plan.Attributes["purchaseTerm"],
+				InstanceFamily:         plan.Attributes["instanceType"],
+				UsageType:              plan.UsageType,
+				Description:            term.Description,
+				EffectiveDate:          term.EffectiveDate,
 				DiscountedSku:          rate.DiscountedSku,
 				LeaseContractLength:    term.LeaseContractLength.Duration,
+				LeaseContractUnit:      term.LeaseContractLength.Unit,
 				DiscountedRate:         rate.DiscountedRate.Price,
+				Currency:               rate.DiscountedRate.Currency,
+				RateCode:               rate.RateCode,
 				RegionID:               regionID,
 				RegionCode:             regionCode,
 				ProviderID:             providerID,
 				DiscountedInstanceType: rate.DiscountedInstanceType, // Correct field name
+				DiscountedUsageType:    rate.DiscountedUsageType,
+				DiscountedOperation:    rate.DiscountedOperation,
+				DiscountedServiceCode:  rate.DiscountedServiceCode,
+				DiscountedRegionCode:   rate.DiscountedRegionCode,
 				Unit:                   rate.Unit, // Correctly assigning Unit
-			}
+			})
+		}
+	}
 
-			// Insert into the database
-			if err := db.Create(&savingPlan).Error; err != nil {
-				log.Printf("Failed to insert SavingPlan for SKU %s: %v", term.Sku, err)
-			}
+	// Insert into the database
+	if len(savingPlans) > 0 {
+		if err := db.CreateInBatches(&savingPlans, 1000).Error; err != nil {
+			return rows, fmt.Errorf("failed to insert SavingPlans for region %s: %v", regionCode, err)
 		}
 	}
+	rows.SavingPlans = int64(len(savingPlans))
+	log.Printf("Inserted %d SavingPlan rates for region %s", len(savingPlans), regionCode)
+
+	return rows, LinkDiscountedSkus(db, regionID)
+}
 
+// LinkDiscountedSkus points the savings plan rates of a region at the
+// on-demand SKUs they discount. It is run again whenever the SKUs of the
+// region are reloaded, since that gives them new IDs.
+func LinkDiscountedSkus(db *gorm.DB, regionID uint) error {
+	err := db.Exec(`UPDATE saving_plans sp SET discounted_sku_id = s.id
+		FROM skus s
+		WHERE sp.region_id = ? AND s.region_id = sp.region_id AND s.sku_code = sp.discounted_sku`, regionID).Error
+	if err != nil {
+		return fmt.Errorf("failed to link SavingPlans to SKUs: %v", err)
+	}
 	return nil
 }
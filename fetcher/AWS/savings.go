@@ -0,0 +1,136 @@
+package AWS
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"cco-package/fetcher/AWS/models"
+	"cco-package/fetcher/AWS/saving"
+	"cco-package/fetcher/AWS/track"
+	"cco-package/fetcher/config"
+	"cco-package/fetcher/history"
+	"cco-package/fetcher/schema"
+)
+
+// RunSavingsPlans ingests only the AWS savings plans, without touching the
+// offer files of the other services. run may be nil.
+func RunSavingsPlans(run *history.Run) error {
+	provider, err := setupAWS()
+	if err != nil {
+		return err
+	}
+	return runSavingPlanStage(provider, run)
+}
+
+// runSavingPlanStage ingests the savings plan rates of every region listed in
+// saving_region_index.json, including regions that have no offer file. It
+// keeps its own manifest, so an interrupted savings plan run only cleans up
+// savings plan data.
+func runSavingPlanStage(provider schema.Provider, run *history.Run) error {
+	manifest, err := resumeManifest("saving_track.json", track.SavingPlanService, func(entry models.RegionState) error {
+		return track.RemoveRegionSavingPlans(config.DB, entry.RegionName)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Download the saving_region_index.json file (Saving Plan)
+	var savingRegionData models.SavingRegionIndex
+	err = downloadJSON(config.SavingRegionURL, filepath.Join(config.PriceListPath, "saving_region_index.json"), &savingRegionData)
+	if err != nil {
+		return fmt.Errorf("failed to read saving region index: %v", err)
+	}
+
+	var jobs []regionJob
+	regionIDs := make(map[string]uint)
+	for _, savingRegion := range savingRegionData.Regions {
+		regionID, err := ensureRegion(regionIDs, savingRegion.RegionCode, provider.ProviderID)
+		if err != nil {
+			return err
+		}
+		version, err := track.VersionFromURL(track.SavingPlanService, savingRegion.VersionUrl)
+		if err != nil {
+			return err
+		}
+
+		jobs = append(jobs, regionJob{
+			ServiceCode:     track.SavingPlanService,
+			RegionCode:      savingRegion.RegionCode,
+			RegionID:        regionID,
+			VersionURL:      savingRegion.VersionUrl,
+			Version:         version,
+			PublicationDate: savingRegionData.PublicationDate,
+		})
+	}
+
+	err = runStageJobs(manifest, jobs, func(job regionJob) error {
+		return processSavingRegion(job, manifest, run)
+	})
+
+	// Drop the cached savings plan files of regions no longer listed
+	pruneCache(jobs, savingCacheName, func(name string) bool {
+		return strings.HasSuffix(name, "-saving.json")
+	})
+	return err
+}
+
+// savingCacheName is the name of the cached savings plan file of a region job.
+func savingCacheName(job regionJob) string {
+	return job.RegionCode + "-saving"
+}
+
+// processSavingRegion downloads and ingests the savings plan file of one
+// region, unless its version was already ingested.
+func processSavingRegion(job regionJob, manifest *track.Manifest, run *history.Run) (err error) {
+	step := run.StartStep("savings_plan", job.ServiceCode, job.RegionCode)
+	var rows models.RowCounts
+	skipped := false
+	defer func() {
+		if skipped {
+			step.Skip()
+		} else {
+			step.Finish(historyRows(rows), err)
+		}
+	}()
+
+	ingested, err := track.IsVersionIngested(config.DB, job.ServiceCode, job.RegionCode, job.Version)
+	if err != nil {
+		return err
+	}
+	if ingested {
+		log.Printf("Skipping saving plans of %s, version %s already ingested", job.RegionCode, job.Version)
+		skipped = true
+		return manifest.UpToDate(job.ServiceCode, job.RegionCode, job.Version)
+	}
+
+	log.Printf("Processing saving plans of %s (version %s)", job.RegionCode, job.Version)
+	if err := manifest.Start(job.ServiceCode, job.RegionCode, job.Version); err != nil {
+		return err
+	}
+	removed, err := track.RemoveSavingPlanData(config.DB, job.RegionID)
+	if err != nil {
+		return fmt.Errorf("failed to remove previous saving plan version: %v", err)
+	}
+
+	savingVersionURL := config.BaseURL + job.VersionURL
+	err = downloadAndProcess(savingVersionURL, savingCacheName(job), func(path string) error {
+		if err := manifest.Parsing(job.ServiceCode, job.RegionCode); err != nil {
+			return err
+		}
+		var err error
+		rows, err = saving.ProcessVersionFile(config.DB, path, job.RegionID)
+		return err
+	})
+	rows.Removed = removed
+	if err != nil {
+		return fmt.Errorf("failed to process saving version file: %v", err)
+	}
+
+	if err := track.SaveVersion(config.DB, job.ServiceCode, job.RegionCode, job.Version, job.PublicationDate); err != nil {
+		return err
+	}
+
+	return manifest.Loaded(job.ServiceCode, job.RegionCode, rows)
+}
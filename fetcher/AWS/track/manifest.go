@@ -0,0 +1,183 @@
+package track
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"cco-package/fetcher/AWS/models"
+)
+
+// Region states recorded in the manifest.
+const (
+	StatePending     = "pending"
+	StateDownloading = "downloading"
+	StateParsing     = "parsing"
+	StateLoaded      = "loaded"
+	StateFailed      = "failed"
+)
+
+// Manifest records the ingestion state of every service/region pair of a
+// stage in a JSON file, which is rewritten after every change so that a
+// crash leaves an accurate picture of what was in flight.
+type Manifest struct {
+	mu      sync.Mutex
+	path    string
+	Regions map[string]*models.RegionState `json:"regions"`
+}
+
+// manifestKey identifies a service/region pair in the manifest.
+func manifestKey(serviceCode, regionCode string) string {
+	return serviceCode + "/" + regionCode
+}
+
+// LoadManifest reads the manifest at path, or returns an empty one when the
+// file does not exist yet. A single-entry track file from older versions is
+// read as one interrupted region of legacyService, the only service the old
+// tracker loaded, so the stage resumes it under its own key.
+func LoadManifest(path, legacyService string) (*Manifest, error) {
+	m := &Manifest{path: path, Regions: make(map[string]*models.RegionState)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %v", err)
+	}
+
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %v", err)
+	}
+	if len(m.Regions) == 0 {
+		m.Regions = make(map[string]*models.RegionState)
+
+		var legacy models.RegionState
+		if err := json.Unmarshal(data, &legacy); err == nil && legacy.RegionName != "" && legacy.State != "processed" {
+			legacy.ServiceCode = legacyService
+			legacy.State = StateDownloading
+			m.Regions[manifestKey(legacyService, legacy.RegionName)] = &legacy
+		}
+	}
+	return m, nil
+}
+
+// Interrupted returns the entries that were downloading or parsing when the
+// previous run stopped; their rows may be partially loaded.
+func (m *Manifest) Interrupted() []models.RegionState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var entries []models.RegionState
+	for _, entry := range m.Regions {
+		if entry.State == StateDownloading || entry.State == StateParsing {
+			entries = append(entries, *entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return manifestKey(entries[i].ServiceCode, entries[i].RegionName) < manifestKey(entries[j].ServiceCode, entries[j].RegionName)
+	})
+	return entries
+}
+
+// Pending marks the service/region pair as queued for the given version,
+// unless that version is already loaded.
+func (m *Manifest) Pending(serviceCode, regionCode, version string) error {
+	return m.update(serviceCode, regionCode, func(entry *models.RegionState, now time.Time) {
+		if entry.State == StateLoaded && entry.SourceVersion == version {
+			return
+		}
+		entry.State = StatePending
+		entry.SourceVersion = version
+	})
+}
+
+// Start marks the service/region pair as downloading.
+func (m *Manifest) Start(serviceCode, regionCode, version string) error {
+	return m.update(serviceCode, regionCode, func(entry *models.RegionState, now time.Time) {
+		entry.State = StateDownloading
+		entry.SourceVersion = version
+		entry.StartedAt = &now
+		entry.FinishedAt = nil
+		entry.Rows = models.RowCounts{}
+		entry.LastError = ""
+	})
+}
+
+// Parsing marks the service/region pair as being parsed and loaded.
+func (m *Manifest) Parsing(serviceCode, regionCode string) error {
+	return m.update(serviceCode, regionCode, func(entry *models.RegionState, now time.Time) {
+		entry.State = StateParsing
+	})
+}
+
+// Loaded marks the service/region pair as fully loaded with the given row counts.
+func (m *Manifest) Loaded(serviceCode, regionCode string, rows models.RowCounts) error {
+	return m.update(serviceCode, regionCode, func(entry *models.RegionState, now time.Time) {
+		entry.State = StateLoaded
+		entry.FinishedAt = &now
+		entry.Rows = rows
+	})
+}
+
+// Failed marks the service/region pair as failed with the given error.
+func (m *Manifest) Failed(serviceCode, regionCode string, cause error) error {
+	return m.update(serviceCode, regionCode, func(entry *models.RegionState, now time.Time) {
+		entry.State = StateFailed
+		entry.FinishedAt = &now
+		entry.LastError = cause.Error()
+	})
+}
+
+// UpToDate marks the service/region pair as loaded when the given version
+// was already ingested by an earlier run, keeping its recorded row counts.
+func (m *Manifest) UpToDate(serviceCode, regionCode, version string) error {
+	return m.update(serviceCode, regionCode, func(entry *models.RegionState, now time.Time) {
+		if entry.State == StateLoaded && entry.SourceVersion == version {
+			return
+		}
+		entry.State = StateLoaded
+		entry.SourceVersion = version
+		entry.FinishedAt = &now
+		entry.LastError = ""
+	})
+}
+
+// update applies change to the entry of the service/region pair and saves the manifest.
+func (m *Manifest) update(serviceCode, regionCode string, change func(*models.RegionState, time.Time)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := manifestKey(serviceCode, regionCode)
+	entry, ok := m.Regions[key]
+	if !ok {
+		entry = &models.RegionState{ServiceCode: serviceCode, RegionName: regionCode, State: StatePending}
+		m.Regions[key] = entry
+	}
+
+	now := time.Now()
+	change(entry, now)
+	entry.UpdatedAt = &now
+	return m.save()
+}
+
+// save writes the manifest to a temp file and renames it over the old one,
+// so a crash never leaves a half-written manifest behind.
+func (m *Manifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %v", err)
+	}
+
+	tmpPath := m.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("error writing manifest: %v", err)
+	}
+	if err := os.Rename(tmpPath, m.path); err != nil {
+		return fmt.Errorf("error replacing manifest: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package track
+
+import (
+	"fmt"
+	"strings"
+
+	"cco-package/fetcher/AWS/models"
+	"cco-package/fetcher/schema"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SavingPlanService is the service code under which savings plan versions are recorded.
+const SavingPlanService = "AWSComputeSavingsPlan"
+
+// VersionFromURL extracts the version ID from an offer file URL such as
+// /offers/v1.0/aws/AmazonEC2/20250512175616/us-east-1/index.json. It fails
+// when the URL does not follow that layout, since storing anything else as
+// the version would defeat skipping versions that were already ingested.
+func VersionFromURL(serviceCode, url string) (string, error) {
+	parts := strings.Split(strings.Trim(url, "/"), "/")
+	for i, part := range parts {
+		if part != serviceCode || i+2 >= len(parts) {
+			continue
+		}
+		version := parts[i+1]
+		if version == "" || strings.Trim(version, "0123456789") != "" {
+			return "", fmt.Errorf("unexpected version %q in offer URL %s", version, url)
+		}
+		return version, nil
+	}
+	return "", fmt.Errorf("offer URL %s has no version after %s", url, serviceCode)
+}
+
+// IsVersionIngested reports whether the given version was already ingested
+// for the service in the region.
+func IsVersionIngested(db *gorm.DB, serviceCode, regionCode, version string) (bool, error) {
+	var count int64
+	err := db.Model(&models.OfferVersion{}).
+		Where("service_code = ? AND region_code = ? AND version = ?", serviceCode, regionCode, version).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to read offer version: %v", err)
+	}
+	return count > 0, nil
+}
+
+// SaveVersion records the version that was just ingested for the service in the region.
+func SaveVersion(db *gorm.DB, serviceCode, regionCode, version, publicationDate string) error {
+	entry := models.OfferVersion{
+		ServiceCode:     serviceCode,
+		RegionCode:      regionCode,
+		Version:         version,
+		PublicationDate: publicationDate,
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "service_code"}, {Name: "region_code"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"version":          version,
+			"publication_date": publicationDate,
+			"modified_date":    gorm.Expr("current_timestamp"),
+		}),
+	}).Create(&entry).Error
+	if err != nil {
+		return fmt.Errorf("failed to save offer version: %v", err)
+	}
+	return nil
+}
+
+// RemoveServiceRegionData deletes the SKUs, prices and terms a service
+// ingested for a region, so a new version can be loaded in their place. It
+// returns the number of rows removed.
+func RemoveServiceRegionData(db *gorm.DB, serviceID, regionID uint) (int64, error) {
+	var removed int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		skuIDs := tx.Model(&schema.SKU{}).Select("id").Where("service_id = ? AND region_id = ?", serviceID, regionID)
+
+		result := tx.Where("sku_id IN (?)", skuIDs).Delete(&schema.Term{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete term data: %v", result.Error)
+		}
+		removed += result.RowsAffected
+
+		result = tx.Where("sku_id IN (?)", skuIDs).Delete(&schema.Price{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete price data: %v", result.Error)
+		}
+		removed += result.RowsAffected
+
+		result = tx.Where("service_id = ? AND region_id = ?", serviceID, regionID).Delete(&schema.SKU{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete SKU data: %v", result.Error)
+		}
+		removed += result.RowsAffected
+		return nil
+	})
+	return removed, err
+}
+
+// RemoveServiceRegionByCode deletes the rows a service ingested for a region
+// by their codes and forgets the ingested version, so the pair is loaded again.
+func RemoveServiceRegionByCode(db *gorm.DB, serviceCode, regionCode string) error {
+	var service models.Service
+	if err := db.Where("service_code = ?", serviceCode).First(&service).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to fetch service: %v", err)
+	}
+	var region schema.Region
+	if err := db.Scopes(awsRegion(regionCode)).First(&region).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to fetch region: %v", err)
+	}
+
+	if _, err := RemoveServiceRegionData(db, service.ServiceID, region.RegionID); err != nil {
+		return err
+	}
+	if err := db.Where("service_code = ? AND region_code = ?", serviceCode, regionCode).Delete(&models.OfferVersion{}).Error; err != nil {
+		return fmt.Errorf("failed to delete offer version: %v", err)
+	}
+	return nil
+}
+
+// RemoveSavingPlanData deletes the savings plan rates of a region and
+// returns the number of rows removed.
+func RemoveSavingPlanData(db *gorm.DB, regionID uint) (int64, error) {
+	result := db.Where("region_id = ?", regionID).Delete(&schema.SavingPlan{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete savings plan data: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// RemoveRegionSavingPlans deletes the savings plan rates of a region by its
+// code and forgets the ingested savings plan version, so the region is
+// loaded again.
+func RemoveRegionSavingPlans(db *gorm.DB, regionCode string) error {
+	var region schema.Region
+	if err := db.Scopes(awsRegion(regionCode)).First(&region).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to fetch region: %v", err)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if _, err := RemoveSavingPlanData(tx, region.RegionID); err != nil {
+			return err
+		}
+		if err := tx.Where("service_code = ? AND region_code = ?", SavingPlanService, regionCode).Delete(&models.OfferVersion{}).Error; err != nil {
+			return fmt.Errorf("failed to delete offer version: %v", err)
+		}
+		return nil
+	})
+}
+
+// awsRegion scopes a regions query to the AWS region with the given code.
+// Region codes are only unique per provider.
+func awsRegion(regionCode string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("provider_id = (SELECT provider_id FROM providers WHERE provider_name = ?) AND region_code = ?", "AWS", regionCode)
+	}
+}
@@ -0,0 +1,33 @@
+package track
+
+import "testing"
+
+func TestVersionFromURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceCode string
+		url         string
+		want        string
+		wantErr     bool
+	}{
+		{"offer file", "AmazonEC2", "/offers/v1.0/aws/AmazonEC2/20250512175616/us-east-1/index.json", "20250512175616", false},
+		{"savings plan file", SavingPlanService, "/savingsPlan/v1.0/aws/AWSComputeSavingsPlan/20250514174616/us-east-1/index.json", "20250514174616", false},
+		{"full URL", "AmazonRDS", "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonRDS/20250401000000/eu-west-1/index.json", "20250401000000", false},
+		{"other service in the path", "AmazonRDS", "/offers/v1.0/aws/AmazonEC2/20250512175616/us-east-1/index.json", "", true},
+		{"current instead of a version", "AmazonEC2", "/offers/v1.0/aws/AmazonEC2/current/us-east-1/index.json", "", true},
+		{"nothing after the version", "AmazonEC2", "/offers/v1.0/aws/AmazonEC2/20250512175616", "", true},
+		{"mirror without the service", "AmazonEC2", "https://mirror.example.com/price-list/us-east-1.json", "", true},
+		{"empty", "AmazonEC2", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VersionFromURL(tt.serviceCode, tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VersionFromURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VersionFromURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package utils
+
+import (
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// md5ETag matches an ETag that is the plain MD5 of the object, as S3 returns
+// for objects that were not uploaded in parts.
+var md5ETag = regexp.MustCompile(`^"?([0-9a-f]{32})"?$`)
+
+// cacheMeta is stored next to a downloaded file as <file>.meta and describes
+// the response it came from.
+type cacheMeta struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Size         int64  `json:"size"`
+}
+
+// Downloader downloads files into a local cache. It sends conditional
+// requests for files it already has, resumes interrupted downloads with
+// Range requests, accepts gzip, checks the status code, size and MD5 ETag of
+// every new download, and only replaces the cached file once the new one is
+// complete.
+type Downloader struct {
+	Client *http.Client
+}
+
+// NewDownloader returns a Downloader whose requests time out after timeout.
+// The connection, TLS handshake and response headers have their own shorter
+// timeouts, so a dead server is noticed long before a slow transfer is.
+func NewDownloader(timeout time.Duration) *Downloader {
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   30 * time.Second,
+		ResponseHeaderTimeout: 2 * time.Minute,
+		IdleConnTimeout:       90 * time.Second,
+		DisableCompression:    true, // gzip is requested and decoded by Download itself
+	}
+	return &Downloader{Client: &http.Client{Transport: transport, Timeout: timeout}}
+}
+
+// defaultDownloader is used by DownloadFile. Offer files of large regions
+// are several gigabytes, hence the generous overall timeout.
+var defaultDownloader = NewDownloader(2 * time.Hour)
+
+// DefaultDownloader returns the Downloader used by DownloadFile.
+func DefaultDownloader() *Downloader {
+	return defaultDownloader
+}
+
+// DownloadFile downloads url to filepath, reusing the cached file when the
+// server reports that it has not changed.
+func DownloadFile(url, filepath string) error {
+	_, err := defaultDownloader.Download(url, filepath)
+	return err
+}
+
+// Download downloads url to path and reports whether the file changed. When
+// path holds a verified earlier download of url, the request is conditional
+// and a 304 response keeps the cached file.
+func (d *Downloader) Download(url, path string) (bool, error) {
+	metaPath := path + ".meta"
+	partPath := path + ".part"
+	partMetaPath := partPath + ".meta"
+
+	cached, cacheOK := readCacheMeta(metaPath)
+	if cacheOK && (cached.URL != url || verifyFile(path, cached) != nil) {
+		cacheOK = false
+	}
+
+	// A partial download can only be resumed if we know which version it belongs to.
+	var offset int64
+	partMeta, partOK := readCacheMeta(partMetaPath)
+	if info, err := os.Stat(partPath); err == nil && partOK && partMeta.URL == url && (partMeta.ETag != "" || partMeta.LastModified != "") {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if cacheOK {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	if offset > 0 {
+		// Byte ranges refer to the unencoded file, so resume without gzip.
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if partMeta.ETag != "" {
+			req.Header.Set("If-Range", partMeta.ETag)
+		} else {
+			req.Header.Set("If-Range", partMeta.LastModified)
+		}
+		req.Header.Set("Accept-Encoding", "identity")
+	} else {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("error fetching %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	var flags int
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cacheOK:
+		return false, nil
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, fmt.Errorf("unexpected status %s for %s: %s", resp.Status, url, strings.TrimSpace(string(body)))
+	}
+
+	meta := cacheMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	encoded := resp.Header.Get("Content-Encoding") == "gzip"
+
+	// Record which version the partial file belongs to before writing it.
+	// A gzip stream cannot be resumed, so no resume metadata is kept for it.
+	if encoded {
+		os.Remove(partMetaPath)
+	} else if err := writeCacheMeta(partMetaPath, meta); err != nil {
+		return false, err
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return false, err
+	}
+
+	var body io.Reader = resp.Body
+	if encoded {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			out.Close()
+			return false, fmt.Errorf("error reading gzip response of %s: %w", url, err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	written, err := io.Copy(out, body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, fmt.Errorf("error writing %s: %w", partPath, err)
+	}
+
+	// Check the size against Content-Length, which only describes the
+	// transferred bytes when the body was not gzip encoded.
+	if !encoded && resp.ContentLength >= 0 && written != resp.ContentLength {
+		return false, fmt.Errorf("incomplete download of %s: got %d of %d bytes", url, written, resp.ContentLength)
+	}
+	if total := contentRangeTotal(resp.Header.Get("Content-Range")); total > 0 && offset+written != total {
+		return false, fmt.Errorf("incomplete download of %s: got %d of %d bytes", url, offset+written, total)
+	}
+
+	// Hash the file only now that it is new; a cached file is trusted by its size.
+	if m := md5ETag.FindStringSubmatch(meta.ETag); m != nil && !encoded {
+		md5sum, err := md5File(partPath)
+		if err != nil {
+			return false, err
+		}
+		if m[1] != md5sum {
+			os.Remove(partPath)
+			os.Remove(partMetaPath)
+			return false, fmt.Errorf("checksum mismatch for %s: ETag %s, got %s", url, m[1], md5sum)
+		}
+	}
+	info, err := os.Stat(partPath)
+	if err != nil {
+		return false, err
+	}
+	meta.Size = info.Size()
+
+	// Replace the cached file only now that the new one is complete.
+	if err := os.Rename(partPath, path); err != nil {
+		return false, err
+	}
+	os.Remove(partMetaPath)
+	if err := writeCacheMeta(metaPath, meta); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// cacheSuffixes are the files kept next to a cached file: its metadata, an
+// unfinished download and the temp files they are written through.
+var cacheSuffixes = []string{".part.meta.tmp", ".part.meta", ".meta.tmp", ".meta", ".part"}
+
+// PruneCache removes the cached files in dir that match but are not in keep,
+// together with their metadata and unfinished downloads, and returns the
+// number of files removed. Each stage calls it with the files of the offers
+// it still lists, so the files of superseded offers, services and regions do
+// not pile up.
+func PruneCache(dir string, keep map[string]bool, match func(name string) bool) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		base := cacheBase(entry.Name())
+		if !match(base) || keep[base] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// cacheBase returns the name of the cached file a file in the cache belongs to.
+func cacheBase(name string) string {
+	for _, suffix := range cacheSuffixes {
+		if base := strings.TrimSuffix(name, suffix); base != name {
+			return base
+		}
+	}
+	return name
+}
+
+// contentRangeTotal returns the complete length from a "bytes a-b/total"
+// Content-Range header, or 0 when it is missing or unknown.
+func contentRangeTotal(header string) int64 {
+	i := strings.LastIndex(header, "/")
+	if i < 0 {
+		return 0
+	}
+	total, err := strconv.ParseInt(header[i+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return total
+}
+
+// verifyFile checks that the file at path has the size recorded in meta. Its
+// content was checked against the ETag when it was downloaded, and the ETag
+// is revalidated by the conditional request, so the file is not hashed again:
+// rehashing a multi-gigabyte offer file on every run would cost most of what
+// the cache saves.
+func verifyFile(path string, meta cacheMeta) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() != meta.Size {
+		return fmt.Errorf("size of %s is %d, expected %d", path, info.Size(), meta.Size)
+	}
+	return nil
+}
+
+// md5File returns the MD5 hex digest of a file.
+func md5File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	sum := md5.New()
+	if _, err := io.Copy(sum, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+func readCacheMeta(path string) (cacheMeta, bool) {
+	var meta cacheMeta
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return meta, false
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, false
+	}
+	return meta, true
+}
+
+// writeCacheMeta writes meta through a temp file and a rename.
+func writeCacheMeta(path string, meta cacheMeta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
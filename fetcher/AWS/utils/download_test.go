@@ -0,0 +1,300 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// offerServer serves body with an MD5 ETag, answering conditional, Range
+// and gzip requests like S3 does, and records the requests it receives.
+type offerServer struct {
+	*httptest.Server
+	body []byte
+	etag string
+	gzip bool // Encode full responses when the client accepts gzip
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func newOfferServer(t *testing.T, body string) *offerServer {
+	s := &offerServer{}
+	s.setBody(body)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *offerServer) setBody(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sum := md5.Sum([]byte(body))
+	s.body = []byte(body)
+	s.etag = `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func (s *offerServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	body, etag := s.body, s.etag
+	s.mu.Unlock()
+
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	var offset int
+	if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &offset); err == nil && r.Header.Get("If-Range") == etag {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(body)-1, len(body)))
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)-offset))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body[offset:])
+		return
+	}
+	if s.gzip && strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		gz.Write(body)
+		gz.Close()
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(buf.Bytes())
+		return
+	}
+	w.Write(body)
+}
+
+func (s *offerServer) lastRequest() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func newTestDownloader() *Downloader {
+	return &Downloader{Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDownloadNotModified(t *testing.T) {
+	server := newOfferServer(t, `{"products": {}}`)
+	path := filepath.Join(t.TempDir(), "offer.json")
+	d := newTestDownloader()
+
+	changed, err := d.Download(server.URL, path)
+	if err != nil || !changed {
+		t.Fatalf("first Download() = %v, %v, want a changed file", changed, err)
+	}
+
+	changed, err = d.Download(server.URL, path)
+	if err != nil || changed {
+		t.Fatalf("second Download() = %v, %v, want the cached file", changed, err)
+	}
+	if got := server.lastRequest().Header.Get("If-None-Match"); got != server.etag {
+		t.Errorf("If-None-Match = %q, want %q", got, server.etag)
+	}
+	if got := readFile(t, path); got != `{"products": {}}` {
+		t.Errorf("cached file = %q", got)
+	}
+
+	// A cached file whose size no longer matches its metadata is downloaded again
+	if err := os.WriteFile(path, []byte(`{"products": {"x"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err = d.Download(server.URL, path)
+	if err != nil || !changed {
+		t.Fatalf("Download() of a corrupted cache = %v, %v, want a changed file", changed, err)
+	}
+	if got := server.lastRequest().Header.Get("If-None-Match"); got != "" {
+		t.Errorf("corrupted cache was revalidated with If-None-Match %q", got)
+	}
+	if got := readFile(t, path); got != `{"products": {}}` {
+		t.Errorf("cached file = %q", got)
+	}
+}
+
+func TestDownloadTrustsCachedFileOfRecordedSize(t *testing.T) {
+	server := newOfferServer(t, `{"products": {}}`)
+	path := filepath.Join(t.TempDir(), "offer.json")
+	d := newTestDownloader()
+
+	if _, err := d.Download(server.URL, path); err != nil {
+		t.Fatal(err)
+	}
+	// Same size, different content: only a hash would notice, and cached
+	// files are not hashed again
+	if err := os.WriteFile(path, []byte(`{"products": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := d.Download(server.URL, path)
+	if err != nil || changed {
+		t.Fatalf("Download() = %v, %v, want the cached file", changed, err)
+	}
+	if got := server.lastRequest().Header.Get("If-None-Match"); got != server.etag {
+		t.Errorf("If-None-Match = %q, want %q", got, server.etag)
+	}
+}
+
+func TestDownloadResumesPartialFile(t *testing.T) {
+	body := `{"products": {"SKU1": {"sku": "SKU1"}}}`
+	server := newOfferServer(t, body)
+	path := filepath.Join(t.TempDir(), "offer.json")
+
+	// An earlier run stopped after the first 10 bytes
+	if err := os.WriteFile(path+".part", []byte(body[:10]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeCacheMeta(path+".part.meta", cacheMeta{URL: server.URL, ETag: server.etag}); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := newTestDownloader().Download(server.URL, path)
+	if err != nil || !changed {
+		t.Fatalf("Download() = %v, %v, want a changed file", changed, err)
+	}
+	request := server.lastRequest()
+	if got := request.Header.Get("Range"); got != "bytes=10-" {
+		t.Errorf("Range = %q, want bytes=10-", got)
+	}
+	if got := request.Header.Get("If-Range"); got != server.etag {
+		t.Errorf("If-Range = %q, want %q", got, server.etag)
+	}
+	if got := readFile(t, path); got != body {
+		t.Errorf("file = %q, want %q", got, body)
+	}
+	for _, leftover := range []string{path + ".part", path + ".part.meta"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", filepath.Base(leftover))
+		}
+	}
+}
+
+func TestDownloadRestartsPartialFileOfAnotherVersion(t *testing.T) {
+	server := newOfferServer(t, `{"version": "new"}`)
+	path := filepath.Join(t.TempDir(), "offer.json")
+
+	if err := os.WriteFile(path+".part", []byte(`{"vers`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeCacheMeta(path+".part.meta", cacheMeta{URL: server.URL, ETag: `"old"`}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newTestDownloader().Download(server.URL, path); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != `{"version": "new"}` {
+		t.Errorf("file = %q, want the new version in full", got)
+	}
+}
+
+func TestDownloadGzip(t *testing.T) {
+	body := strings.Repeat(`{"sku": "SKU1"},`, 100)
+	server := newOfferServer(t, body)
+	server.gzip = true
+	path := filepath.Join(t.TempDir(), "offer.json")
+
+	if _, err := newTestDownloader().Download(server.URL, path); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.lastRequest().Header.Get("Accept-Encoding"); got != "gzip" {
+		t.Errorf("Accept-Encoding = %q, want gzip", got)
+	}
+	if got := readFile(t, path); got != body {
+		t.Errorf("file was not decoded, got %d bytes", len(got))
+	}
+}
+
+func TestDownloadChecksumMismatch(t *testing.T) {
+	server := newOfferServer(t, `{"products": {}}`)
+	server.etag = `"0123456789abcdef0123456789abcdef"` // Not the MD5 of the body
+	dir := t.TempDir()
+	path := filepath.Join(dir, "offer.json")
+	if err := os.WriteFile(path, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := newTestDownloader().Download(server.URL, path)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("Download() error = %v, want a checksum mismatch", err)
+	}
+	if got := readFile(t, path); got != "previous" {
+		t.Errorf("cached file was replaced by a corrupt download: %q", got)
+	}
+	for _, leftover := range []string{path + ".part", path + ".part.meta", path + ".meta"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind", filepath.Base(leftover))
+		}
+	}
+}
+
+func TestDownloadUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := newTestDownloader().Download(server.URL, filepath.Join(t.TempDir(), "offer.json"))
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Download() error = %v, want the 403 status", err)
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"AmazonEC2-us-east-1.json", "AmazonEC2-us-east-1.json.meta",
+		"AmazonEC2-eu-west-9.json", "AmazonEC2-eu-west-9.json.meta",
+		"AmazonEC2-ap-east-2.json.part", "AmazonEC2-ap-east-2.json.part.meta", "AmazonEC2-ap-east-2.json.part.meta.tmp",
+		"us-east-1-saving.json", "index.json", "index.json.meta",
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keep := map[string]bool{"AmazonEC2-us-east-1.json": true}
+	removed, err := PruneCache(dir, keep, func(name string) bool {
+		return strings.HasPrefix(name, "AmazonEC2-")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 5 {
+		t.Errorf("removed %d files, want 5", removed)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+	sort.Strings(left)
+	want := []string{"AmazonEC2-us-east-1.json", "AmazonEC2-us-east-1.json.meta", "index.json", "index.json.meta", "us-east-1-saving.json"}
+	if strings.Join(left, ",") != strings.Join(want, ",") {
+		t.Errorf("files left = %v, want %v", left, want)
+	}
+}
@@ -1,31 +1,8 @@
 package utils
 
-import (
-	"io"
-	"net/http"
-	"os"
-)
-
 func DefaultIfEmpty(value, defaultValue string) string {
 	if value == "" {
 		return defaultValue
 	}
 	return value
 }
-
-func DownloadFile(url, filepath string) error {
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	out, err := os.Create(filepath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
-	return err
-}
\ No newline at end of file
@@ -3,41 +3,37 @@ package Azure
 import (
 	"cco-package/fetcher/Azure/services"
 	"cco-package/fetcher/config"
+	"cco-package/fetcher/history"
 	"log"
 )
 
 // RunAzure fetches data from Azure and returns an error if any step fails.
-func RunAzure() error {
+// Every import is recorded as a step of run, which may be nil.
+func RunAzure(run *history.Run) error {
 	// Initialize the database
-	if err := config.ConnectDatabase(); err != nil {
+	if err := config.EnsureDatabase(); err != nil {
 		return err
 	}
 
-	// Import data from Azure VM API
-	// if err := services.ImportData(); err != nil {
-	// 	log.Printf("Error importing Azure VM data: %v", err)
-	// 	return err
-	// }
-	// log.Println("Azure VM data import completed successfully.")
-
-	// Uncomment if needed
-	// if err := services.ImportSkuData(); err != nil {
-	// 	log.Printf("Error importing SKU data: %v", err)
-	// 	return err
-	// }
-	// log.Println("SKU data import completed successfully.")
-
-	// if err := services.ImportPricesData(); err != nil {
-	// 	log.Printf("Error importing prices data: %v", err)
-	// 	return err
-	// }
-	// log.Println("Prices data import completed successfully.")
-
-	// Import terms data
-	if err := services.ImportTermsData(); err != nil {
-		log.Printf("Error importing terms data: %v", err)
+	// Load regions, SKUs, prices and terms of Virtual Machines in one pass
+	// over the retail price feed. An interrupted pass resumes from its
+	// checkpoint on the next run.
+	step := run.StartStep("virtual_machines", "Virtual Machines", "")
+	rows, err := services.ImportVirtualMachines()
+	step.Finish(rows, err)
+	if err != nil {
+		log.Printf("Error importing Azure Virtual Machines: %v", err)
 		return err
 	}
 
+	// Import region location metadata. The catalogue fields are optional,
+	// so a failure is recorded but does not stop the run.
+	step = run.StartStep("locations", "Virtual Machines", "")
+	err = services.ImportLocations()
+	step.Finish(history.Rows{}, err)
+	if err != nil {
+		log.Printf("Error importing Azure locations: %v", err)
+	}
+
 	return nil // No errors
 }
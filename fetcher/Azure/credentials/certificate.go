@@ -0,0 +1,148 @@
+package credentials
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// assertionLifetime is how long a signed client assertion is valid.
+const assertionLifetime = 10 * time.Minute
+
+// NewClientCertificateCredential authenticates with a certificate registered
+// on the app. certificatePath is a PEM file holding the certificate and its
+// unencrypted RSA private key; each token request is authenticated with a
+// client assertion JWT signed by the key.
+func NewClientCertificateCredential(tenantID, clientID, certificatePath string, options Options) (Credential, error) {
+	data, err := os.ReadFile(certificatePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading client certificate: %w", err)
+	}
+	certificate, key, err := parseCertificate(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing client certificate %s: %w", certificatePath, err)
+	}
+
+	var c *client
+	c, err = newClient(tenantID, clientID, options, func(form url.Values) error {
+		assertion, err := signAssertion(c.clientID, c.tokenURL, certificate, key)
+		if err != nil {
+			return err
+		}
+		form.Set("client_assertion_type", jwtBearerAssertion)
+		form.Set("client_assertion", assertion)
+		return nil
+	})
+	return credential(c, err)
+}
+
+// parseCertificate returns the first certificate and the RSA private key of
+// a PEM file.
+func parseCertificate(data []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	var certificate *x509.Certificate
+	var key *rsa.PrivateKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			if certificate != nil {
+				continue
+			}
+			parsed, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			certificate = parsed
+		case "PRIVATE KEY":
+			parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			rsaKey, ok := parsed.(*rsa.PrivateKey)
+			if !ok {
+				return nil, nil, errors.New("private key is not an RSA key")
+			}
+			key = rsaKey
+		case "RSA PRIVATE KEY":
+			parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			key = parsed
+		}
+	}
+	if certificate == nil {
+		return nil, nil, errors.New("no certificate found")
+	}
+	if key == nil {
+		return nil, nil, errors.New("no unencrypted RSA private key found")
+	}
+	return certificate, key, nil
+}
+
+// signAssertion returns an RS256 client assertion for the token endpoint.
+// The x5t header is the SHA-1 thumbprint Entra ID looks the certificate up by.
+func signAssertion(clientID, audience string, certificate *x509.Certificate, key *rsa.PrivateKey) (string, error) {
+	thumbprint := sha1.Sum(certificate.Raw)
+	header := map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"x5t": base64.RawURLEncoding.EncodeToString(thumbprint[:]),
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := map[string]interface{}{
+		"aud": audience,
+		"iss": clientID,
+		"sub": clientID,
+		"jti": hex.EncodeToString(jti),
+		"nbf": now.Unix(),
+		"iat": now.Unix(),
+		"exp": now.Add(assertionLifetime).Unix(),
+	}
+
+	encodedHeader, err := encodeSegment(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodedHeader + "." + encodedClaims
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("error signing client assertion: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func encodeSegment(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,287 @@
+// Package credentials gets Microsoft Entra ID access tokens for the Azure
+// management API with the client credentials flow. A client can prove its
+// identity with a secret, a certificate or a federated workload identity
+// token; tokens are cached until shortly before they expire.
+package credentials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the credentials.
+const (
+	DefaultAuthorityHost = "https://login.microsoftonline.com"
+	ManagementScope      = "https://management.azure.com/.default"
+
+	// refreshMargin is how long before expiry a cached token is renewed.
+	refreshMargin = 5 * time.Minute
+
+	jwtBearerAssertion = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+// Token is an access token and the time it expires.
+type Token struct {
+	AccessToken string
+	ExpiresOn   time.Time
+}
+
+// Credential gets access tokens for a scope.
+type Credential interface {
+	Token(ctx context.Context, scope string) (Token, error)
+}
+
+// Options configures how a credential reaches the authority.
+type Options struct {
+	// AuthorityHost is the Entra ID host, DefaultAuthorityHost when empty.
+	// Sovereign clouds use their own, e.g. https://login.chinacloudapi.cn.
+	AuthorityHost string
+	// TokenURL replaces the whole token endpoint, e.g. a local fake authority
+	// in tests. It defaults to AuthorityHost/<tenant>/oauth2/v2.0/token.
+	TokenURL   string
+	HTTPClient *http.Client
+}
+
+// client requests tokens from the token endpoint of a tenant and caches them
+// per scope. assert adds the client authentication to the form.
+type client struct {
+	tenantID string
+	clientID string
+	tokenURL string
+	http     *http.Client
+	assert   func(form url.Values) error
+
+	mu     sync.Mutex
+	tokens map[string]Token
+}
+
+func newClient(tenantID, clientID string, options Options, assert func(url.Values) error) (*client, error) {
+	if tenantID == "" || clientID == "" {
+		return nil, errors.New("tenant ID and client ID are required")
+	}
+	tokenURL := options.TokenURL
+	if tokenURL == "" {
+		host := options.AuthorityHost
+		if host == "" {
+			host = DefaultAuthorityHost
+		}
+		tokenURL = strings.TrimRight(host, "/") + "/" + url.PathEscape(tenantID) + "/oauth2/v2.0/token"
+	}
+	httpClient := options.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Minute}
+	}
+	return &client{
+		tenantID: tenantID,
+		clientID: clientID,
+		tokenURL: tokenURL,
+		http:     httpClient,
+		assert:   assert,
+		tokens:   make(map[string]Token),
+	}, nil
+}
+
+// Token returns the cached token of the scope, or requests a new one when
+// there is none or it expires within refreshMargin.
+func (c *client) Token(ctx context.Context, scope string) (Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if token, ok := c.tokens[scope]; ok && time.Until(token.ExpiresOn) > refreshMargin {
+		return token, nil
+	}
+	token, err := c.request(ctx, scope)
+	if err != nil {
+		return Token{}, err
+	}
+	c.tokens[scope] = token
+	return token, nil
+}
+
+// request posts a client_credentials grant to the token endpoint.
+func (c *client) request(ctx context.Context, scope string) (Token, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", c.clientID)
+	form.Set("scope", scope)
+	if err := c.assert(form); err != nil {
+		return Token{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("error creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("error making token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Token{}, fmt.Errorf("error reading token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Token{}, fmt.Errorf("non-200 response: %d, body: %s", resp.StatusCode, body)
+	}
+
+	var response struct {
+		AccessToken string          `json:"access_token"`
+		ExpiresIn   json.RawMessage `json:"expires_in"` // A number, or a string holding one
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return Token{}, fmt.Errorf("error decoding token response: %w", err)
+	}
+	if response.AccessToken == "" {
+		return Token{}, errors.New("access_token not found in response")
+	}
+	expiresIn, err := strconv.Atoi(strings.Trim(string(response.ExpiresIn), `"`))
+	if err != nil {
+		return Token{}, fmt.Errorf("invalid expires_in %s in token response", response.ExpiresIn)
+	}
+
+	return Token{
+		AccessToken: response.AccessToken,
+		ExpiresOn:   time.Now().Add(time.Duration(expiresIn) * time.Second),
+	}, nil
+}
+
+// NewClientSecretCredential authenticates with a client secret.
+func NewClientSecretCredential(tenantID, clientID, secret string, options Options) (Credential, error) {
+	if secret == "" {
+		return nil, errors.New("client secret is required")
+	}
+	return credential(newClient(tenantID, clientID, options, func(form url.Values) error {
+		form.Set("client_secret", secret)
+		return nil
+	}))
+}
+
+// NewWorkloadIdentityCredential authenticates with a federated token, such as
+// the service account token Kubernetes projects into tokenFile. The file is
+// read on every request, since the token in it is rotated.
+func NewWorkloadIdentityCredential(tenantID, clientID, tokenFile string, options Options) (Credential, error) {
+	if tokenFile == "" {
+		return nil, errors.New("federated token file is required")
+	}
+	return credential(newClient(tenantID, clientID, options, func(form url.Values) error {
+		assertion, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return fmt.Errorf("error reading federated token: %w", err)
+		}
+		form.Set("client_assertion_type", jwtBearerAssertion)
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+		return nil
+	}))
+}
+
+// credential returns c as a Credential, or a nil Credential on error.
+func credential(c *client, err error) (Credential, error) {
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Chain tries its credentials in order and returns the first token one of
+// them gets. The credential that succeeded is tried first from then on, so
+// a misconfigured credential does not cost a request every time.
+type Chain struct {
+	mu          sync.Mutex
+	credentials []Credential
+	preferred   int
+}
+
+// NewChain returns a chain of the given credentials.
+func NewChain(credentials ...Credential) *Chain {
+	return &Chain{credentials: credentials}
+}
+
+// Token implements Credential.
+func (c *Chain) Token(ctx context.Context, scope string) (Token, error) {
+	if len(c.credentials) == 0 {
+		return Token{}, errors.New("no Azure credentials configured")
+	}
+	c.mu.Lock()
+	preferred := c.preferred
+	c.mu.Unlock()
+
+	var errs []error
+	for n := 0; n < len(c.credentials); n++ {
+		i := (preferred + n) % len(c.credentials)
+		token, err := c.credentials[i].Token(ctx, scope)
+		if err == nil {
+			c.mu.Lock()
+			c.preferred = i
+			c.mu.Unlock()
+			return token, nil
+		}
+		errs = append(errs, err)
+	}
+	return Token{}, fmt.Errorf("no credential could get a token: %w", errors.Join(errs...))
+}
+
+// FromEnvironment builds the chain of the credentials configured in the
+// environment, in this order:
+//
+//	AZURE_FEDERATED_TOKEN_FILE      workload identity federation
+//	AZURE_CLIENT_CERTIFICATE_PATH   client certificate (PEM with the private key)
+//	AZURE_CLIENT_SECRET             client secret
+//
+// AZURE_TENANT_ID and AZURE_CLIENT_ID are required, AZURE_AUTHORITY_HOST is
+// optional.
+func FromEnvironment() (*Chain, error) {
+	tenantID := os.Getenv("AZURE_TENANT_ID")
+	clientID := os.Getenv("AZURE_CLIENT_ID")
+	if tenantID == "" || clientID == "" {
+		missing := []string{}
+		if tenantID == "" {
+			missing = append(missing, "AZURE_TENANT_ID")
+		}
+		if clientID == "" {
+			missing = append(missing, "AZURE_CLIENT_ID")
+		}
+		return nil, fmt.Errorf("missing required environment variables: %v", missing)
+	}
+	options := Options{AuthorityHost: os.Getenv("AZURE_AUTHORITY_HOST")}
+
+	var credentials []Credential
+	if tokenFile := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); tokenFile != "" {
+		credential, err := NewWorkloadIdentityCredential(tenantID, clientID, tokenFile, options)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	if certificatePath := os.Getenv("AZURE_CLIENT_CERTIFICATE_PATH"); certificatePath != "" {
+		credential, err := NewClientCertificateCredential(tenantID, clientID, certificatePath, options)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	if secret := os.Getenv("AZURE_CLIENT_SECRET"); secret != "" {
+		credential, err := NewClientSecretCredential(tenantID, clientID, secret, options)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	if len(credentials) == 0 {
+		return nil, errors.New("missing required environment variables: one of AZURE_CLIENT_SECRET, AZURE_CLIENT_CERTIFICATE_PATH or AZURE_FEDERATED_TOKEN_FILE")
+	}
+	return NewChain(credentials...), nil
+}
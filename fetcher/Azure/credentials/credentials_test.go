@@ -0,0 +1,362 @@
+package credentials
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAuthority is a token endpoint that records the forms it receives and
+// answers with a numbered token.
+type fakeAuthority struct {
+	*httptest.Server
+	expiresIn string // JSON value of expires_in
+
+	mu    sync.Mutex
+	paths []string
+	forms []url.Values
+}
+
+func newFakeAuthority(t *testing.T, expiresIn string) *fakeAuthority {
+	a := &fakeAuthority{expiresIn: expiresIn}
+	a.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.mu.Lock()
+		a.paths = append(a.paths, r.URL.Path)
+		a.forms = append(a.forms, r.PostForm)
+		n := len(a.forms)
+		a.mu.Unlock()
+
+		if r.PostForm.Get("client_id") == "rejected" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"token_type":"Bearer","access_token":"token-%d","expires_in":%s}`, n, a.expiresIn)
+	}))
+	t.Cleanup(a.Close)
+	return a
+}
+
+func (a *fakeAuthority) requests() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.forms)
+}
+
+func (a *fakeAuthority) form(i int) url.Values {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.forms[i]
+}
+
+func (a *fakeAuthority) tokenURL() string {
+	return a.URL + "/token"
+}
+
+func TestClientSecretCredential(t *testing.T) {
+	authority := newFakeAuthority(t, "3600")
+	credential, err := NewClientSecretCredential("tenant", "client", "secret", Options{AuthorityHost: authority.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := credential.Token(context.Background(), ManagementScope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token-1" {
+		t.Errorf("AccessToken = %q, want token-1", token.AccessToken)
+	}
+	if remaining := time.Until(token.ExpiresOn); remaining < 59*time.Minute || remaining > time.Hour {
+		t.Errorf("token expires in %v, want about an hour", remaining)
+	}
+
+	if got, want := authority.paths[0], "/tenant/oauth2/v2.0/token"; got != want {
+		t.Errorf("token path = %q, want %q", got, want)
+	}
+	form := authority.form(0)
+	for field, want := range map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     "client",
+		"client_secret": "secret",
+		"scope":         ManagementScope,
+	} {
+		if got := form.Get(field); got != want {
+			t.Errorf("form %s = %q, want %q", field, got, want)
+		}
+	}
+}
+
+func TestTokenCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn string
+		requests  int
+	}{
+		{"valid token is reused", "3600", 1},
+		{"expires_in as a string", `"3600"`, 1},
+		{"token expiring within the refresh margin is renewed", "60", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authority := newFakeAuthority(t, tt.expiresIn)
+			credential, err := NewClientSecretCredential("tenant", "client", "secret", Options{TokenURL: authority.tokenURL()})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var token Token
+			for i := 0; i < 3; i++ {
+				if token, err = credential.Token(context.Background(), ManagementScope); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := authority.requests(); got != tt.requests {
+				t.Errorf("token requests = %d, want %d", got, tt.requests)
+			}
+			if want := fmt.Sprintf("token-%d", tt.requests); token.AccessToken != want {
+				t.Errorf("AccessToken = %q, want %q", token.AccessToken, want)
+			}
+		})
+	}
+}
+
+func TestTokenCacheIsPerScope(t *testing.T) {
+	authority := newFakeAuthority(t, "3600")
+	credential, err := NewClientSecretCredential("tenant", "client", "secret", Options{TokenURL: authority.tokenURL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scope := range []string{ManagementScope, "https://storage.azure.com/.default", ManagementScope} {
+		if _, err := credential.Token(context.Background(), scope); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := authority.requests(); got != 2 {
+		t.Errorf("token requests = %d, want 2", got)
+	}
+}
+
+func TestTokenErrors(t *testing.T) {
+	authority := newFakeAuthority(t, "3600")
+	credential, err := NewClientSecretCredential("tenant", "rejected", "secret", Options{TokenURL: authority.tokenURL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := credential.Token(context.Background(), ManagementScope); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Token() error = %v, want the 401 response", err)
+	}
+
+	invalid := newFakeAuthority(t, `"soon"`)
+	credential, err = NewClientSecretCredential("tenant", "client", "secret", Options{TokenURL: invalid.tokenURL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := credential.Token(context.Background(), ManagementScope); err == nil {
+		t.Error("Token() accepted an invalid expires_in")
+	}
+}
+
+func TestWorkloadIdentityCredential(t *testing.T) {
+	authority := newFakeAuthority(t, "60") // Renewed on every request
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	credential, err := NewWorkloadIdentityCredential("tenant", "client", tokenFile, Options{TokenURL: authority.tokenURL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The projected token is rotated; every request reads the current one
+	for i, federated := range []string{"federated-1", "federated-2"} {
+		if err := os.WriteFile(tokenFile, []byte(federated+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := credential.Token(context.Background(), ManagementScope); err != nil {
+			t.Fatal(err)
+		}
+		form := authority.form(i)
+		if got := form.Get("client_assertion"); got != federated {
+			t.Errorf("client_assertion = %q, want %q", got, federated)
+		}
+		if got := form.Get("client_assertion_type"); got != jwtBearerAssertion {
+			t.Errorf("client_assertion_type = %q, want %q", got, jwtBearerAssertion)
+		}
+		if form.Has("client_secret") {
+			t.Error("workload identity request sent a client secret")
+		}
+	}
+
+	if err := os.Remove(tokenFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := credential.Token(context.Background(), ManagementScope); err == nil {
+		t.Error("Token() succeeded without a federated token file")
+	}
+}
+
+func TestClientCertificateCredential(t *testing.T) {
+	authority := newFakeAuthority(t, "3600")
+	certificate, key, path := writeCertificate(t)
+	credential, err := NewClientCertificateCredential("tenant", "client", path, Options{TokenURL: authority.tokenURL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := credential.Token(context.Background(), ManagementScope); err != nil {
+		t.Fatal(err)
+	}
+
+	form := authority.form(0)
+	if got := form.Get("client_assertion_type"); got != jwtBearerAssertion {
+		t.Errorf("client_assertion_type = %q, want %q", got, jwtBearerAssertion)
+	}
+	parts := strings.Split(form.Get("client_assertion"), ".")
+	if len(parts) != 3 {
+		t.Fatalf("client_assertion has %d parts, want 3", len(parts))
+	}
+
+	var header map[string]string
+	decodeSegment(t, parts[0], &header)
+	thumbprint := sha1.Sum(certificate.Raw)
+	if header["alg"] != "RS256" || header["x5t"] != base64.RawURLEncoding.EncodeToString(thumbprint[:]) {
+		t.Errorf("header = %v, want RS256 with the certificate thumbprint", header)
+	}
+
+	var claims map[string]interface{}
+	decodeSegment(t, parts[1], &claims)
+	if claims["aud"] != authority.tokenURL() || claims["iss"] != "client" || claims["sub"] != "client" {
+		t.Errorf("claims = %v, want the token URL as audience and the client as issuer", claims)
+	}
+	if exp, nbf := claims["exp"].(float64), claims["nbf"].(float64); exp-nbf != assertionLifetime.Seconds() {
+		t.Errorf("assertion is valid for %vs, want %v", exp-nbf, assertionLifetime)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("assertion signature does not verify: %v", err)
+	}
+}
+
+func TestChainPrefersTheCredentialThatSucceeded(t *testing.T) {
+	authority := newFakeAuthority(t, "60") // Renewed on every request
+	rejected, err := NewClientSecretCredential("tenant", "rejected", "secret", Options{TokenURL: authority.tokenURL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted, err := NewClientSecretCredential("tenant", "client", "secret", Options{TokenURL: authority.tokenURL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := NewChain(rejected, accepted)
+
+	for i := 0; i < 2; i++ {
+		if _, err := chain.Token(context.Background(), ManagementScope); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The rejected credential is only tried once
+	if got := authority.requests(); got != 3 {
+		t.Errorf("token requests = %d, want 3", got)
+	}
+
+	if _, err := NewChain(rejected).Token(context.Background(), ManagementScope); err == nil {
+		t.Error("Token() succeeded although every credential failed")
+	}
+}
+
+func TestFromEnvironment(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "tenant")
+	t.Setenv("AZURE_CLIENT_ID", "")
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+	t.Setenv("AZURE_CLIENT_CERTIFICATE_PATH", "")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+	if _, err := FromEnvironment(); err == nil || !strings.Contains(err.Error(), "AZURE_CLIENT_ID") {
+		t.Errorf("FromEnvironment() error = %v, want AZURE_CLIENT_ID missing", err)
+	}
+
+	authority := newFakeAuthority(t, "3600")
+	t.Setenv("AZURE_CLIENT_ID", "client")
+	t.Setenv("AZURE_AUTHORITY_HOST", authority.URL)
+	chain, err := FromEnvironment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.Token(context.Background(), ManagementScope); err != nil {
+		t.Fatal(err)
+	}
+	if got := authority.form(0).Get("client_secret"); got != "secret" {
+		t.Errorf("client_secret = %q, want secret", got)
+	}
+}
+
+// writeCertificate writes a self-signed certificate and its PKCS8 key to a
+// PEM file.
+func writeCertificate(t *testing.T) (*x509.Certificate, *rsa.PrivateKey, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fetcher"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})...)
+	path := filepath.Join(t.TempDir(), "client.pem")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certificate, key, path
+}
+
+func decodeSegment(t *testing.T, segment string, value interface{}) {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		t.Fatal(err)
+	}
+}
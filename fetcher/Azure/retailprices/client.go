@@ -0,0 +1,216 @@
+package retailprices
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of a new Client.
+const (
+	DefaultBaseURL    = "https://prices.azure.com/api/retail/prices"
+	DefaultAPIVersion = "2023-01-01-preview"
+	DefaultMaxRetries = 5
+)
+
+// Client queries the Retail Prices API.
+type Client struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	APIVersion string
+	MaxRetries int           // Retries of a throttled (429) or failed (5xx) request
+	MinBackoff time.Duration // First delay when the response has no Retry-After
+	MaxBackoff time.Duration
+}
+
+// New returns a client with the default settings.
+func New() *Client {
+	return &Client{
+		HTTPClient: &http.Client{Timeout: 2 * time.Minute},
+		BaseURL:    DefaultBaseURL,
+		APIVersion: DefaultAPIVersion,
+		MaxRetries: DefaultMaxRetries,
+		MinBackoff: time.Second,
+		MaxBackoff: time.Minute,
+	}
+}
+
+// Query selects the prices to list.
+type Query struct {
+	Filter   Filter
+	Currency string // currencyCode, e.g. "EUR"; the API defaults to USD
+}
+
+// URL returns the URL of the first page of the query.
+func (c *Client) URL(query Query) string {
+	pageURL := c.BaseURL + "?api-version=" + escape(c.APIVersion)
+	if query.Currency != "" {
+		pageURL += "&currencyCode=" + escape("'"+query.Currency+"'")
+	}
+	if filter := query.Filter.String(); filter != "" {
+		pageURL += "&$filter=" + escape(filter)
+	}
+	return pageURL
+}
+
+// Pages returns an iterator over the pages of the query.
+func (c *Client) Pages(ctx context.Context, query Query) *Iterator {
+	return &Iterator{client: c, ctx: ctx, next: c.URL(query)}
+}
+
+// PagesFrom returns an iterator that starts at pageURL, usually a
+// NextPageLink saved by an earlier, interrupted iteration.
+func (c *Client) PagesFrom(ctx context.Context, pageURL string) *Iterator {
+	return &Iterator{client: c, ctx: ctx, next: pageURL}
+}
+
+// GetPage fetches and decodes one page, retrying throttled and failed
+// requests.
+func (c *Client) GetPage(ctx context.Context, pageURL string) (*Page, error) {
+	body, err := c.get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	var page Page
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("error decoding retail prices page: %w", err)
+	}
+	return &page, nil
+}
+
+// get returns the body of a successful response. 429 and 5xx responses and
+// network errors are retried up to MaxRetries times, waiting as long as the
+// Retry-After header asks or backing off exponentially.
+func (c *Client) get(ctx context.Context, pageURL string) ([]byte, error) {
+	backoff := c.MinBackoff
+	for attempt := 0; ; attempt++ {
+		body, retryAfter, err := c.do(ctx, pageURL)
+		if err == nil {
+			return body, nil
+		}
+		if retryAfter < 0 || attempt >= c.MaxRetries {
+			return nil, err
+		}
+
+		delay := retryAfter
+		if delay == 0 {
+			delay = backoff
+			backoff *= 2
+			if backoff > c.MaxBackoff {
+				backoff = c.MaxBackoff
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// do sends one request. retryAfter is negative when the error is final, and
+// otherwise the delay the server asked for, 0 when it did not say.
+func (c *Client) do(ctx context.Context, pageURL string) (body []byte, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, -1, fmt.Errorf("error creating HTTP request: %w", err)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, ctx.Err()
+		}
+		return nil, 0, fmt.Errorf("error fetching retail prices: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading response body: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return body, 0, nil
+	}
+
+	err = fmt.Errorf("received non-200 response: %d, body: %s", resp.StatusCode, body)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), err
+	}
+	return nil, -1, err
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, given in seconds
+// or as an HTTP date, and 0 when there is none.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// escape escapes a query parameter value. Spaces become %20, since the API
+// does not accept "+" in $filter.
+func escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// Iterator walks the pages of a query by following NextPageLink.
+//
+//	for it := client.Pages(ctx, query); it.Next(); {
+//		for _, item := range it.Page().Items { ... }
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator struct {
+	client *Client
+	ctx    context.Context
+	next   string
+	page   *Page
+	err    error
+}
+
+// Next fetches the next page and reports whether there is one. It returns
+// false at the end of the results or on error, see Err.
+func (it *Iterator) Next() bool {
+	if it.err != nil || it.next == "" {
+		return false
+	}
+	page, err := it.client.GetPage(it.ctx, it.next)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page = page
+	it.next = page.NextPageLink
+	return true
+}
+
+// Page returns the page fetched by the last call to Next.
+func (it *Iterator) Page() *Page {
+	return it.page
+}
+
+// NextPageLink returns the URL of the page the next call to Next fetches,
+// "" after the last page.
+func (it *Iterator) NextPageLink() string {
+	return it.next
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
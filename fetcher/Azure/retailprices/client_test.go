@@ -0,0 +1,212 @@
+package retailprices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testClient returns a client of server with backoffs short enough for tests.
+func testClient(server *httptest.Server) *Client {
+	return &Client{
+		HTTPClient: server.Client(),
+		BaseURL:    server.URL,
+		APIVersion: DefaultAPIVersion,
+		MaxRetries: 3,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 4 * time.Millisecond,
+	}
+}
+
+// page writes a page with one item per meter ID, linking to next if it is set.
+func page(w http.ResponseWriter, next string, meterIDs ...string) {
+	var items []string
+	for _, id := range meterIDs {
+		items = append(items, fmt.Sprintf(`{"meterId": %q, "retailPrice": 0.096, "type": "Consumption"}`, id))
+	}
+	fmt.Fprintf(w, `{"BillingCurrency": "USD", "Items": [%s], "NextPageLink": %q, "Count": %d}`,
+		strings.Join(items, ","), next, len(meterIDs))
+}
+
+func TestPagesFollowsNextPageLink(t *testing.T) {
+	var server *httptest.Server
+	var filters []string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filters = append(filters, r.URL.Query().Get("$filter"))
+		switch r.URL.Query().Get("page") {
+		case "":
+			page(w, server.URL+"?page=2", "m1", "m2")
+		case "2":
+			page(w, server.URL+"?page=3", "m3")
+		default:
+			page(w, "", "m4")
+		}
+	}))
+	defer server.Close()
+
+	query := Query{Filter: NewFilter().ServiceName("Virtual Machines")}
+	it := testClient(server).Pages(context.Background(), query)
+	var meters []string
+	var links []string
+	for it.Next() {
+		for _, item := range it.Page().Items {
+			meters = append(meters, item.MeterID)
+		}
+		links = append(links, it.NextPageLink())
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(meters, ","); got != "m1,m2,m3,m4" {
+		t.Errorf("meters = %s, want m1,m2,m3,m4", got)
+	}
+	if want := []string{server.URL + "?page=2", server.URL + "?page=3", ""}; strings.Join(links, " ") != strings.Join(want, " ") {
+		t.Errorf("NextPageLink() after each page = %q, want %q", links, want)
+	}
+	if filters[0] != "serviceName eq 'Virtual Machines'" {
+		t.Errorf("$filter of the first page = %q", filters[0])
+	}
+	if it.Next() {
+		t.Error("Next() after the last page = true")
+	}
+}
+
+func TestPagesFromResumesAtLink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("$skip") != "1000" {
+			t.Errorf("resumed at %s, want the saved link", r.URL)
+		}
+		page(w, "", "m1001")
+	}))
+	defer server.Close()
+
+	it := testClient(server).PagesFrom(context.Background(), server.URL+"?$skip=1000")
+	if !it.Next() || it.Page().Items[0].MeterID != "m1001" {
+		t.Fatalf("Next() did not return the saved page: %v", it.Err())
+	}
+}
+
+func TestGetPageRetries(t *testing.T) {
+	pastDate := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name      string
+		responses []int  // Status of each attempt; the last one repeats
+		header    string // Retry-After of the failed responses
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"success", []int{200}, "", 1, false},
+		{"throttled then success", []int{429, 429, 200}, "", 3, false},
+		{"server errors then success", []int{500, 503, 200}, "", 3, false},
+		{"retry-after date in the past", []int{429, 200}, pastDate, 2, false},
+		{"retries exhausted", []int{503}, "", 4, true},
+		{"client error is final", []int{400}, "", 1, true},
+		{"not found is final", []int{404, 200}, "", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(atomic.AddInt32(&calls, 1)) - 1
+				status := tt.responses[min(n, len(tt.responses)-1)]
+				if status != http.StatusOK {
+					if tt.header != "" {
+						w.Header().Set("Retry-After", tt.header)
+					}
+					http.Error(w, "try again", status)
+					return
+				}
+				page(w, "", "m1")
+			}))
+			defer server.Close()
+
+			_, err := testClient(server).GetPage(context.Background(), server.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetPage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetPageWaitsForRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "throttled", http.StatusTooManyRequests)
+			return
+		}
+		page(w, "", "m1")
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	if _, err := testClient(server).GetPage(context.Background(), server.URL); err != nil {
+		t.Fatal(err)
+	}
+	// The client backs off for a millisecond on its own, so only the
+	// header explains a wait of a second
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("retried after %v, want the 1s of Retry-After", elapsed)
+	}
+}
+
+func TestGetPageStopsWhenContextIsDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := testClient(server)
+	client.MinBackoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetPage(ctx, server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetPage() error = %v, want the context deadline", err)
+	}
+}
+
+func TestGetPageInvalidJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Items": [`)
+	}))
+	defer server.Close()
+
+	if _, err := testClient(server).GetPage(context.Background(), server.URL); err == nil {
+		t.Error("GetPage() accepted a truncated page")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"5", 5 * time.Second, 5 * time.Second},
+		{"120", 2 * time.Minute, 2 * time.Minute},
+		{"0", 0, 0},
+		{"-3", 0, 0},
+		{"soon", 0, 0},
+		{now.Add(30 * time.Second).UTC().Format(http.TimeFormat), 28 * time.Second, 30 * time.Second},
+		{now.Add(-30 * time.Second).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package retailprices
+
+import (
+	"strings"
+	"time"
+)
+
+// Filter builds the OData $filter of a query. The conditions are joined with
+// "and"; a zero Filter matches every price.
+//
+//	retailprices.NewFilter().ServiceName("Virtual Machines").Region("westeurope").Type(retailprices.TypeConsumption)
+type Filter struct {
+	conditions []string
+}
+
+// NewFilter returns an empty filter.
+func NewFilter() Filter {
+	return Filter{}
+}
+
+// Eq adds the condition field eq 'value'.
+func (f Filter) Eq(field, value string) Filter {
+	return f.add(field + " eq " + quote(value))
+}
+
+// ServiceName matches the service, e.g. "Virtual Machines".
+func (f Filter) ServiceName(name string) Filter {
+	return f.Eq("serviceName", name)
+}
+
+// ServiceFamily matches the service family, e.g. "Compute".
+func (f Filter) ServiceFamily(family string) Filter {
+	return f.Eq("serviceFamily", family)
+}
+
+// Region matches the API name of the region, e.g. "westeurope".
+func (f Filter) Region(armRegionName string) Filter {
+	return f.Eq("armRegionName", armRegionName)
+}
+
+// Type matches the price type, e.g. TypeReservation.
+func (f Filter) Type(priceType string) Filter {
+	return f.Eq("priceType", priceType)
+}
+
+// SkuName matches the SKU name, e.g. "D2s v3 Spot".
+func (f Filter) SkuName(name string) Filter {
+	return f.Eq("skuName", name)
+}
+
+// ArmSkuName matches the ARM SKU name, e.g. "Standard_D2s_v3".
+func (f Filter) ArmSkuName(name string) Filter {
+	return f.Eq("armSkuName", name)
+}
+
+// EffectiveFrom matches prices effective on or after the given time.
+func (f Filter) EffectiveFrom(t time.Time) Filter {
+	return f.add("effectiveStartDate ge " + t.UTC().Format(time.RFC3339))
+}
+
+// String returns the $filter expression.
+func (f Filter) String() string {
+	return strings.Join(f.conditions, " and ")
+}
+
+// add returns a copy of the filter with the condition, so a base filter can
+// be extended in several ways.
+func (f Filter) add(condition string) Filter {
+	conditions := make([]string, len(f.conditions), len(f.conditions)+1)
+	copy(conditions, f.conditions)
+	return Filter{conditions: append(conditions, condition)}
+}
+
+// quote returns value as an OData string literal.
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
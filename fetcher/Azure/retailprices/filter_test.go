@@ -0,0 +1,83 @@
+package retailprices
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilter(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	tests := []struct {
+		name   string
+		filter Filter
+		want   string
+	}{
+		{"zero filter", Filter{}, ""},
+		{"empty filter", NewFilter(), ""},
+		{"one condition", NewFilter().ServiceName("Virtual Machines"), "serviceName eq 'Virtual Machines'"},
+		{
+			"conditions joined with and",
+			NewFilter().ServiceName("Virtual Machines").Region("westeurope").Type(TypeConsumption),
+			"serviceName eq 'Virtual Machines' and armRegionName eq 'westeurope' and priceType eq 'Consumption'",
+		},
+		{
+			"every field",
+			NewFilter().ServiceFamily("Compute").SkuName("D2s v3 Spot").ArmSkuName("Standard_D2s_v3").Eq("meterId", "abc"),
+			"serviceFamily eq 'Compute' and skuName eq 'D2s v3 Spot' and armSkuName eq 'Standard_D2s_v3' and meterId eq 'abc'",
+		},
+		{"quote in a value is doubled", NewFilter().Eq("productName", "Virtual Machines D'Series"), "productName eq 'Virtual Machines D''Series'"},
+		{"value that tries to close the literal", NewFilter().SkuName("x' or '1' eq '1"), "skuName eq 'x'' or ''1'' eq ''1'"},
+		{
+			"effective date in UTC",
+			NewFilter().EffectiveFrom(time.Date(2025, 5, 1, 1, 30, 0, 0, cet)),
+			"effectiveStartDate ge 2025-05-01T00:30:00Z",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.String(); got != tt.want {
+			t.Errorf("%s: String() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterExtendsCopies(t *testing.T) {
+	base := NewFilter().ServiceName("Virtual Machines")
+	westeurope := base.Region("westeurope")
+	eastus := base.Region("eastus")
+
+	if got := base.String(); got != "serviceName eq 'Virtual Machines'" {
+		t.Errorf("base filter changed to %q", got)
+	}
+	if got := westeurope.String(); got != "serviceName eq 'Virtual Machines' and armRegionName eq 'westeurope'" {
+		t.Errorf("westeurope filter = %q", got)
+	}
+	if got := eastus.String(); got != "serviceName eq 'Virtual Machines' and armRegionName eq 'eastus'" {
+		t.Errorf("eastus filter = %q", got)
+	}
+}
+
+func TestClientURL(t *testing.T) {
+	client := &Client{BaseURL: "https://prices.example.com/api", APIVersion: "2023-01-01-preview"}
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{"no filter", Query{}, "https://prices.example.com/api?api-version=2023-01-01-preview"},
+		{
+			"spaces as %20 and quotes escaped",
+			Query{Filter: NewFilter().ServiceName("Virtual Machines")},
+			"https://prices.example.com/api?api-version=2023-01-01-preview&$filter=serviceName%20eq%20%27Virtual%20Machines%27",
+		},
+		{
+			"currency",
+			Query{Currency: "EUR", Filter: NewFilter().Region("westeurope")},
+			"https://prices.example.com/api?api-version=2023-01-01-preview&currencyCode=%27EUR%27&$filter=armRegionName%20eq%20%27westeurope%27",
+		},
+	}
+	for _, tt := range tests {
+		if got := client.URL(tt.query); got != tt.want {
+			t.Errorf("%s: URL() =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package retailprices is a client for the Azure Retail Prices API
+// (https://prices.azure.com/api/retail/prices). It decodes pages into typed
+// RetailPrice items, builds the OData $filter, follows NextPageLink and
+// retries throttled and failed requests.
+package retailprices
+
+import "encoding/json"
+
+// Price types of the API.
+const (
+	TypeConsumption        = "Consumption"
+	TypeReservation        = "Reservation"
+	TypeDevTestConsumption = "DevTestConsumption"
+)
+
+// Page is one page of the API response.
+type Page struct {
+	BillingCurrency    string        `json:"BillingCurrency"`
+	CustomerEntityID   string        `json:"CustomerEntityId"`
+	CustomerEntityType string        `json:"CustomerEntityType"`
+	Items              []RetailPrice `json:"Items"`
+	NextPageLink       string        `json:"NextPageLink"`
+	Count              int           `json:"Count"`
+}
+
+// RetailPrice is one item of the API. Prices are kept as json.Number, so
+// they can be stored as exact decimals.
+type RetailPrice struct {
+	CurrencyCode         string             `json:"currencyCode"`
+	TierMinimumUnits     float64            `json:"tierMinimumUnits"`
+	ReservationTerm      string             `json:"reservationTerm"` // "1 Year" or "3 Years" for reservations
+	RetailPrice          json.Number        `json:"retailPrice"`
+	UnitPrice            json.Number        `json:"unitPrice"`
+	ArmRegionName        string             `json:"armRegionName"` // e.g. "westeurope"
+	Location             string             `json:"location"`      // Billing location, e.g. "EU West"
+	EffectiveStartDate   string             `json:"effectiveStartDate"`
+	EffectiveEndDate     string             `json:"effectiveEndDate"`
+	MeterID              string             `json:"meterId"`
+	MeterName            string             `json:"meterName"`
+	ProductID            string             `json:"productId"`
+	SkuID                string             `json:"skuId"`
+	ProductName          string             `json:"productName"`
+	SkuName              string             `json:"skuName"` // e.g. "D2s v3", "D2s v3 Spot" or "D2s v3 Low Priority"
+	ServiceName          string             `json:"serviceName"`
+	ServiceID            string             `json:"serviceId"`
+	ServiceFamily        string             `json:"serviceFamily"`
+	UnitOfMeasure        string             `json:"unitOfMeasure"`
+	Type                 string             `json:"type"` // TypeConsumption, TypeReservation or TypeDevTestConsumption
+	IsPrimaryMeterRegion *bool              `json:"isPrimaryMeterRegion"`
+	ArmSkuName           string             `json:"armSkuName"` // e.g. "Standard_D2s_v3"
+	SavingsPlan          []SavingsPlanPrice `json:"savingsPlan"`
+}
+
+// SavingsPlanPrice is the savings plan rate of a Consumption price for one term.
+type SavingsPlanPrice struct {
+	UnitPrice   json.Number `json:"unitPrice"`
+	RetailPrice json.Number `json:"retailPrice"`
+	Term        string      `json:"term"` // "1 Year" or "3 Years"
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// checkpointPath is the file the position of ImportVirtualMachines in the
+// retail price feed is kept in, next to the track files of the AWS stages.
+const checkpointPath = "azure_track.json"
+
+// checkpoint is the position of an ingestion in the retail price feed. It
+// is saved after every page, so an interrupted run resumes with the page
+// after the last one that was fully loaded.
+type checkpoint struct {
+	NextPageLink string    `json:"next_page_link"`
+	Pages        int       `json:"pages"` // Pages loaded so far
+	StartedAt    time.Time `json:"started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// loadCheckpoint reads the checkpoint at path, or returns an empty one when
+// the file does not exist, i.e. the previous run finished.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &checkpoint{StartedAt: time.Now()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %v", err)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint: %v", err)
+	}
+	return &cp, nil
+}
+
+// save writes the checkpoint to a temp file and renames it over the old one,
+// so a crash never leaves a truncated checkpoint behind.
+func (cp *checkpoint) save(path string) error {
+	cp.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding checkpoint: %v", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("error replacing checkpoint: %v", err)
+	}
+	return nil
+}
+
+// clearCheckpoint removes the checkpoint once the whole feed is loaded.
+func clearCheckpoint(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing checkpoint: %v", err)
+	}
+	return nil
+}
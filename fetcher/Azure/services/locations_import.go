@@ -0,0 +1,91 @@
+package services
+
+import (
+	"cco-package/fetcher/Azure/utils"
+	"cco-package/fetcher/config"
+	"cco-package/fetcher/regions"
+	"cco-package/fetcher/schema"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// ImportLocations fills the catalogue fields of the Azure regions from the
+// ARM locations API, which has the display name and coordinates of every
+// physical region. Regions are created by the price imports; locations
+// without a region row are skipped.
+func ImportLocations() error {
+	utils.LoadEnv()
+
+	subscriptionID := os.Getenv("AZURE_SUBSCRIPTION_ID")
+	if subscriptionID == "" {
+		return fmt.Errorf("subscription ID not found in environment variables")
+	}
+	locationsApiUrl := fmt.Sprintf(
+		"https://management.azure.com/subscriptions/%s/locations?api-version=2022-12-01",
+		subscriptionID,
+	)
+
+	providerID, err := azureProviderID()
+	if err != nil {
+		return err
+	}
+
+	bearerToken, err := utils.GenerateBearerToken()
+	if err != nil {
+		return fmt.Errorf("error generating bearer token: %w", err)
+	}
+
+	locationData, err := utils.FetchDataWithBearerToken(locationsApiUrl, bearerToken)
+	if err != nil {
+		return fmt.Errorf("error fetching locations: %w", err)
+	}
+
+	locations, ok := locationData["value"].([]interface{})
+	if !ok {
+		return fmt.Errorf("invalid format for locations")
+	}
+
+	updated := 0
+	for _, locationInterface := range locations {
+		location, ok := locationInterface.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := safeString(location["name"])
+		displayName, _ := safeString(location["displayName"])
+		metadata, _ := location["metadata"].(map[string]interface{})
+
+		// Logical locations such as "europe" or "global" are not regions
+		if regionType, _ := safeString(metadata["regionType"]); regionType != "Physical" {
+			continue
+		}
+
+		info := regions.Azure(name, displayName, parseCoordinate(metadata["latitude"]), parseCoordinate(metadata["longitude"]))
+		result := config.DB.Model(&schema.Region{}).Where("provider_id = ? AND region_code = ?", providerID, name).Updates(info.Columns())
+		if result.Error != nil {
+			log.Printf("Error updating region %s: %v", name, result.Error)
+			continue
+		}
+		if result.RowsAffected > 0 {
+			updated++
+		}
+	}
+
+	log.Printf("Updated location metadata of %d Azure regions", updated)
+	return nil
+}
+
+// parseCoordinate parses a latitude or longitude, which the API returns as a string
+func parseCoordinate(value interface{}) *float64 {
+	str, ok := safeString(value)
+	if !ok {
+		return nil
+	}
+	coordinate, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return nil
+	}
+	return &coordinate
+}
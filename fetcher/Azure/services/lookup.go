@@ -0,0 +1,15 @@
+package services
+
+import (
+	"cco-package/fetcher/config"
+	"fmt"
+)
+
+// azureProviderID returns the provider ID of Azure
+func azureProviderID() (uint, error) {
+	var providerID uint
+	if err := config.DB.Table("providers").Select("provider_id").Where("provider_name = ?", "Azure").Scan(&providerID).Error; err != nil || providerID == 0 {
+		return 0, fmt.Errorf("failed to fetch provider ID for Azure: %v", err)
+	}
+	return providerID, nil
+}
@@ -0,0 +1,554 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"cco-package/fetcher/Azure/retailprices"
+	"cco-package/fetcher/Azure/utils"
+	"cco-package/fetcher/config"
+	"cco-package/fetcher/convertData"
+	"cco-package/fetcher/history"
+	"cco-package/fetcher/quality"
+	"cco-package/fetcher/regions"
+	"cco-package/fetcher/schema"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ImportVirtualMachines loads the Virtual Machines retail price feed in one
+// pass. Each item is written in dependency order: its region, its SKU with
+// the capabilities of the resource SKU of the same size and region, its
+// price, the reservation term of the price and its savings plan rates. The
+// NextPageLink of every loaded page is checkpointed, so an interrupted run
+// resumes where it stopped. Writes are upserts, so a page that was partly
+// loaded before the interruption is loaded again without duplicates. Once the
+// whole feed is loaded, the SKUs, prices and terms that are no longer in it
+// are disabled and the savings plan rates that are no longer in it are
+// removed.
+func ImportVirtualMachines() (history.Rows, error) {
+	var rows history.Rows
+	utils.LoadEnv()
+
+	subscriptionID := os.Getenv("AZURE_SUBSCRIPTION_ID")
+	if subscriptionID == "" {
+		return rows, fmt.Errorf("subscription ID not found in environment variables")
+	}
+
+	// Insert Provider once, since it remains constant
+	provider := schema.Provider{ProviderName: "Azure"}
+	if err := config.DB.Where("provider_name = ?", provider.ProviderName).FirstOrCreate(&provider).Error; err != nil {
+		return rows, fmt.Errorf("error inserting provider: %v", err)
+	}
+
+	bearerToken, err := utils.GenerateBearerToken()
+	if err != nil {
+		return rows, fmt.Errorf("error generating bearer token: %w", err)
+	}
+
+	// Index the resource SKUs by (name, location) once, so each price item is
+	// matched in constant time and only to the capabilities of its own region
+	skuIndex, err := FetchSkuIndex(subscriptionID, bearerToken)
+	if err != nil {
+		return rows, err
+	}
+	log.Printf("Indexed %d resource SKUs by name and location", len(skuIndex))
+
+	cp, err := loadCheckpoint(checkpointPath)
+	if err != nil {
+		return rows, err
+	}
+	var pages *retailprices.Iterator
+	if cp.NextPageLink != "" {
+		log.Printf("Resuming Azure retail prices after page %d", cp.Pages)
+		pages = retailClient.PagesFrom(context.Background(), cp.NextPageLink)
+	} else {
+		pages = retailClient.Pages(context.Background(), vmPriceQuery)
+	}
+
+	importer := &vmImporter{
+		providerID: provider.ProviderID,
+		skuIndex:   skuIndex,
+		regions:    make(map[string]schema.Region),
+		skus:       make(map[string]schema.SKU),
+	}
+	for pages.Next() {
+		for _, item := range pages.Page().Items {
+			importer.importItem(item)
+		}
+
+		cp.Pages++
+		cp.NextPageLink = pages.NextPageLink()
+		if err := cp.save(checkpointPath); err != nil {
+			return importer.rows, err
+		}
+	}
+	if err := pages.Err(); err != nil {
+		return importer.rows, fmt.Errorf("error fetching price data after page %d: %w", cp.Pages, err)
+	}
+
+	// Every row still in the feed was written since the checkpoint started
+	disabled, err := disableStaleRows(provider.ProviderID, cp.StartedAt)
+	if err != nil {
+		return importer.rows, err
+	}
+	importer.rows.Disabled += disabled
+	removed, err := removeStaleSavingPlans(provider.ProviderID, cp.StartedAt)
+	if err != nil {
+		return importer.rows, err
+	}
+	importer.rows.Disabled += removed
+
+	log.Printf("Azure Virtual Machines import completed: %d pages, %d rows inserted, %d updated, %d disabled or removed",
+		cp.Pages, importer.rows.Inserted, importer.rows.Updated, importer.rows.Disabled)
+	return importer.rows, clearCheckpoint(checkpointPath)
+}
+
+// vmImporter writes the items of the feed. The regions and SKUs it wrote are
+// cached, since every SKU appears once per price type and term.
+type vmImporter struct {
+	providerID uint
+	skuIndex   SkuIndex
+	regions    map[string]schema.Region // By armRegionName
+	skus       map[string]schema.SKU    // By armRegionName and skuId
+	rows       history.Rows
+}
+
+// importItem writes one item of the feed. Items that cannot be written are
+// logged and skipped, as they were by the separate imports.
+func (im *vmImporter) importItem(item retailprices.RetailPrice) {
+	if item.ArmSkuName == "" {
+		log.Printf("Missing armSkuName for skuId: %s", item.SkuID)
+		return
+	}
+	region, err := im.region(item)
+	if err != nil {
+		log.Printf("Error inserting region %s: %v", item.ArmRegionName, err)
+		return
+	}
+	sku, ok := im.sku(item, region)
+	if !ok {
+		return
+	}
+
+	// Validate the effective start date
+	if _, err := time.Parse(time.RFC3339, item.EffectiveStartDate); err != nil {
+		log.Printf("Invalid effective start date for skuId: %s, skipping...", item.SkuID)
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		price, err := im.upsertPrice(tx, item, sku)
+		if err != nil {
+			return err
+		}
+		if item.Type == retailprices.TypeReservation {
+			if err := im.upsertTerm(tx, reservationTermRow(sku, price, item.ReservationTerm)); err != nil {
+				return err
+			}
+		}
+		for _, plan := range item.SavingsPlan {
+			savingPlan, ok := savingPlanRow(item, plan, sku, region, im.providerID)
+			if !ok {
+				continue
+			}
+			if err := im.upsertSavingPlan(tx, savingPlan); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error inserting price for skuId: %s, error: %v", item.SkuID, err)
+	}
+}
+
+// region returns the region of the item, creating it with its catalogue
+// fields when it does not exist. ImportLocations refines the catalogue fields.
+func (im *vmImporter) region(item retailprices.RetailPrice) (schema.Region, error) {
+	if region, ok := im.regions[item.ArmRegionName]; ok {
+		return region, nil
+	}
+
+	region := schema.Region{
+		ProviderID: im.providerID,
+		RegionCode: item.ArmRegionName, // API name of the region, e.g. "westeurope"
+		RegionName: item.Location,      // Billing location, e.g. "EU West"
+	}
+	err := config.DB.Where("provider_id = ? AND region_code = ?", region.ProviderID, region.RegionCode).FirstOrCreate(&region).Error
+	if err != nil {
+		return region, err
+	}
+	if info := regions.Azure(region.RegionCode, "", nil, nil); region.LocationKey == "" && info.LocationKey != "" {
+		if err := config.DB.Model(&region).Updates(info.Columns()).Error; err != nil {
+			log.Printf("Error updating region %s: %v", region.RegionCode, err)
+		}
+	}
+
+	im.regions[item.ArmRegionName] = region
+	return region, nil
+}
+
+// sku returns the SKU of the item, inserting it or refreshing its
+// capabilities the first time the run sees it. It reports false when the
+// size has no resource SKU in the region or the SKU cannot be written.
+func (im *vmImporter) sku(item retailprices.RetailPrice, region schema.Region) (schema.SKU, bool) {
+	key := item.ArmRegionName + "/" + item.SkuID
+	if sku, ok := im.skus[key]; ok {
+		return sku, true
+	}
+
+	// Match the resource SKU of the same size in the same region
+	resourceSku := im.skuIndex.Lookup(item.ArmSkuName, item.ArmRegionName)
+	if resourceSku == nil {
+		log.Printf("No matching SKU found for armSkuName: %s in %s", item.ArmSkuName, item.ArmRegionName)
+		return schema.SKU{}, false
+	}
+	capabilities := resourceSku.Capabilities
+
+	// MemoryGB is reported in GiB; store NULL and record values that cannot be parsed
+	memoryGB := capabilities.Raw["MemoryGB"]
+	memoryGiB, err := convertData.ParseMemoryGiB(memoryGB)
+	if err != nil {
+		recordIssue("skus", item.SkuID, "memory_gib", memoryGB, err)
+	}
+
+	// MaxResourceVolumeMB is the local temporary disk, 0 when the VM has none
+	maxResourceVolumeMB := capabilities.MaxResourceVolumeMB
+	storageInfo, err := convertData.ParseResourceVolumeMB(maxResourceVolumeMB)
+	if err != nil {
+		recordIssue("skus", item.SkuID, "total_local_gb", maxResourceVolumeMB, err)
+	}
+
+	sku := schema.SKU{
+		RegionID:           region.RegionID,
+		ProviderID:         im.providerID,
+		RegionCode:         region.RegionCode,
+		ArmSkuName:         item.ArmSkuName,
+		InstanceType:       resourceSku.Name,
+		Type:               item.Type,
+		SKUCode:            item.SkuID,
+		ProductFamily:      resourceSku.Family,
+		Memory:             memoryGB,
+		MemoryGiB:          memoryGiB,
+		CpuArchitecture:    capabilities.CpuArchitectureType,
+		Network:            capabilities.Raw["MaxNetworkInterfaces"],
+		EnhancedNetworking: capabilities.Raw["AcceleratedNetworkingEnabled"],
+		GPU:                capabilities.Raw["GPUs"],
+		MaxIOPS:            capabilities.Raw["UncachedDiskIOPS"],
+		MaxThroughput:      capabilities.Raw["UncachedDiskBytesPerSecond"], // Bytes per second
+		ACUs:               capabilities.ACUs,
+		MaxDataDiskCount:   capabilities.MaxDataDiskCount,
+		PremiumIO:          capabilities.PremiumIO,
+		HyperVGenerations:  capabilities.HyperVGenerations,
+		Capabilities:       capabilities.JSON(),
+		ModifiedDate:       time.Now(),
+	}
+	if capabilities.VCPUs != nil {
+		sku.VCPU = *capabilities.VCPUs
+	}
+	if storageInfo != nil {
+		sku.Storage = maxResourceVolumeMB
+		sku.EBSOnly = &storageInfo.EBSOnly
+		sku.DiskCount = storageInfo.DiskCount
+		sku.DiskSizeGB = storageInfo.DiskSizeGB
+		sku.TotalLocalGB = storageInfo.TotalLocalGB
+	}
+
+	// Use FirstOrCreate on the SKU identity to prevent duplicate SKU insertions;
+	// the capabilities of an existing SKU are refreshed
+	attributes := sku
+	result := config.DB.Where("provider_id = ? AND region_id = ? AND sku_code = ?", im.providerID, region.RegionID, sku.SKUCode).Assign(attributes).FirstOrCreate(&sku)
+	if result.Error != nil {
+		log.Printf("Error inserting SKU %s: %v", item.SkuID, result.Error)
+		return schema.SKU{}, false
+	}
+	// Assign skips zero values, so a SKU back in the feed is enabled here
+	if sku.DisableFlag {
+		if err := config.DB.Model(&sku).Update("disable_flag", false).Error; err != nil {
+			log.Printf("Error enabling SKU %s: %v", item.SkuID, err)
+			return schema.SKU{}, false
+		}
+	}
+
+	im.skus[key] = sku
+	return sku, true
+}
+
+// upsertPrice inserts the price of the item, or updates it when the SKU
+// already has a price with the same rate code, currency and tier.
+func (im *vmImporter) upsertPrice(tx *gorm.DB, item retailprices.RetailPrice, sku schema.SKU) (schema.Price, error) {
+	price := schema.Price{
+		SKU_ID:               sku.ID,                            // Foreign key referencing SKU table
+		TermType:             termType(item.Type, item.SkuName), // Same split as AWS: OnDemand, Reserved, ...
+		RateCode:             rateCode(item),                    // Identifies the price within the SKU
+		PriceType:            item.Type,                         // "Consumption", "Reservation" or "DevTestConsumption"
+		PricePerUnit:         item.RetailPrice.String(),         // Exact decimal string, as for the other providers
+		Currency:             item.CurrencyCode,                 // ISO currency code of the price
+		Unit:                 item.UnitOfMeasure,                // Unit of measurement
+		BeginRange:           item.TierMinimumUnits,             // Lower usage bound of the tier
+		EffectiveDate:        item.EffectiveStartDate,           // Effective date for the price
+		MeterID:              item.MeterID,
+		MeterName:            item.MeterName,
+		ProductName:          item.ProductName,
+		SkuName:              item.SkuName,
+		IsSpot:               isSpot(item.SkuName),
+		IsLowPriority:        isLowPriority(item.SkuName),
+		IsPrimaryMeterRegion: item.IsPrimaryMeterRegion,
+	}
+
+	var existing schema.Price
+	err := tx.Where("sku_id = ? AND rate_code = ? AND currency = ? AND begin_range = ?",
+		price.SKU_ID, price.RateCode, price.Currency, price.BeginRange).
+		Limit(1).Find(&existing).Error
+	if err != nil {
+		return price, err
+	}
+	if existing.PriceID == 0 {
+		if err := tx.Create(&price).Error; err != nil {
+			return price, err
+		}
+		im.rows.Inserted++
+		return price, nil
+	}
+
+	price.PriceID = existing.PriceID
+	price.CreatedDate = existing.CreatedDate
+	price.ModifiedDate = time.Now()
+	if err := tx.Save(&price).Error; err != nil {
+		return price, err
+	}
+	im.rows.Updated++
+	return price, nil
+}
+
+// upsertTerm inserts the term, or updates the term of the same price and
+// lease contract length.
+func (im *vmImporter) upsertTerm(tx *gorm.DB, term schema.Term) error {
+	var existing schema.Term
+	err := tx.Where("price_id = ? AND lease_contract_length = ?", term.PriceID, term.LeaseContractLength).
+		Limit(1).Find(&existing).Error
+	if err != nil {
+		return err
+	}
+	if existing.OfferTermID == 0 {
+		if err := tx.Create(&term).Error; err != nil {
+			return err
+		}
+		im.rows.Inserted++
+		return nil
+	}
+
+	term.OfferTermID = existing.OfferTermID
+	term.CreatedDate = existing.CreatedDate
+	term.ModifiedDate = time.Now()
+	if err := tx.Save(&term).Error; err != nil {
+		return err
+	}
+	im.rows.Updated++
+	return nil
+}
+
+// savingPlanUpdateColumns are the columns of a savings plan rate refreshed
+// when it is loaded again.
+var savingPlanUpdateColumns = []string{
+	"discounted_sku", "discounted_sku_id", "plan_type", "purchase_term", "description",
+	"effective_date", "lease_contract_length", "lease_contract_unit", "discounted_rate",
+	"currency", "discounted_instance_type", "discounted_usage_type", "discounted_service_code",
+	"discounted_region_code", "unit", "modified_date",
+}
+
+// azureSavingPlanRates is the predicate of the unique index on the region and
+// rate code of Azure savings plan rates, whose rate codes savingPlanRow builds.
+const azureSavingPlanRates = "rate_code LIKE '%.SavingsPlan.%'"
+
+// upsertSavingPlan writes the savings plan rate on (region_id, rate_code),
+// so overlapping runs and reloaded pages update the rate instead of adding
+// another one.
+func (im *vmImporter) upsertSavingPlan(tx *gorm.DB, savingPlan schema.SavingPlan) error {
+	var existing int64
+	err := tx.Model(&schema.SavingPlan{}).
+		Where("region_id = ? AND rate_code = ?", savingPlan.RegionID, savingPlan.RateCode).
+		Count(&existing).Error
+	if err != nil {
+		return err
+	}
+
+	savingPlan.ModifiedDate = time.Now()
+	err = tx.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "region_id"}, {Name: "rate_code"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: azureSavingPlanRates}}},
+		DoUpdates:   clause.AssignmentColumns(savingPlanUpdateColumns),
+	}).Create(&savingPlan).Error
+	if err != nil {
+		return err
+	}
+	if existing > 0 {
+		im.rows.Updated++
+	} else {
+		im.rows.Inserted++
+	}
+	return nil
+}
+
+// removeStaleSavingPlans deletes the Azure savings plan rates that were not
+// written since the ingestion started, i.e. rates no longer in the feed, and
+// returns the number of rows removed.
+func removeStaleSavingPlans(providerID uint, since time.Time) (int64, error) {
+	result := config.DB.Where("provider_id = ? AND modified_date < ?", providerID, since).Delete(&schema.SavingPlan{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("error removing stale savings plan rates: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// staleRows are the Azure rows disabled when they were not written by a full
+// pass over the feed. Prices and terms belong to the provider through their SKU.
+var staleRows = []struct {
+	name  string
+	model interface{}
+	where string
+}{
+	{"prices", &schema.Price{}, "sku_id IN (SELECT id FROM skus WHERE provider_id = ?)"},
+	{"terms", &schema.Term{}, "sku_id IN (SELECT id FROM skus WHERE provider_id = ?)"},
+	{"SKUs", &schema.SKU{}, "provider_id = ?"},
+}
+
+// disableStaleRows disables the Azure SKUs, prices and reservation terms
+// that were not written since the ingestion started, i.e. rows no longer in
+// the feed, and returns the number of rows disabled. They are disabled rather
+// than deleted, so the price history closes their prices and the rows come
+// back enabled if the feed lists them again.
+func disableStaleRows(providerID uint, since time.Time) (int64, error) {
+	var disabled int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, stale := range staleRows {
+			result := tx.Model(stale.model).
+				Where(stale.where, providerID).
+				Where("modified_date < ? AND NOT COALESCE(disable_flag, false)", since).
+				Update("disable_flag", true)
+			if result.Error != nil {
+				return fmt.Errorf("error disabling stale %s: %v", stale.name, result.Error)
+			}
+			disabled += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return disabled, nil
+}
+
+// rateCode identifies a price within its SKU, like the rate code of an AWS
+// price: the meter, the price type and the reservation term, e.g.
+// "<meterId>.Reservation.1Year".
+func rateCode(item retailprices.RetailPrice) string {
+	code := item.MeterID + "." + item.Type
+	if item.ReservationTerm != "" {
+		code += "." + strings.ReplaceAll(item.ReservationTerm, " ", "")
+	}
+	return code
+}
+
+// termType maps the type and skuName of a retail price onto the term types
+// used for AWS, so Azure prices can be compared with them. Spot and Low
+// Priority are Consumption prices of their own SKUs.
+func termType(priceType, skuName string) string {
+	switch priceType {
+	case retailprices.TypeReservation:
+		return "Reserved"
+	case retailprices.TypeDevTestConsumption:
+		return "DevTest"
+	}
+	switch {
+	case isSpot(skuName):
+		return "Spot"
+	case isLowPriority(skuName):
+		return "LowPriority"
+	}
+	return "OnDemand"
+}
+
+// isSpot reports whether skuName is a Spot SKU, e.g. "D2s v3 Spot".
+func isSpot(skuName string) bool {
+	return strings.HasSuffix(skuName, " Spot")
+}
+
+// isLowPriority reports whether skuName is a Low Priority SKU, e.g. "D2s v3 Low Priority".
+func isLowPriority(skuName string) bool {
+	return strings.HasSuffix(skuName, " Low Priority")
+}
+
+// reservationTermRow builds the Term of a reservation price. The retail
+// price of a reservation is the total for the whole term ("1 Year" or
+// "3 Years"), not an hourly rate.
+func reservationTermRow(sku schema.SKU, price schema.Price, reservationTerm string) schema.Term {
+	// Parse the term into years, recording values that cannot be parsed
+	leaseContractYears, err := convertData.ParseYears(reservationTerm)
+	if err != nil {
+		recordIssue("terms", sku.SKUCode, "lease_contract_years", reservationTerm, err)
+	}
+
+	return schema.Term{
+		SKU_ID:              sku.ID,
+		PriceID:             price.PriceID,
+		LeaseContractLength: reservationTerm,
+		LeaseContractYears:  leaseContractYears,
+		CreatedDate:         time.Now(),
+		ModifiedDate:        time.Now(),
+		DisableFlag:         false,
+	}
+}
+
+// savingPlanRow builds the SavingPlan of the savings plan rate of a
+// Consumption price. Azure savings plans for compute apply to any VM size
+// and region, like AWS Compute Savings Plans, so they are stored with the
+// same plan type and term format. It reports false, after recording an
+// issue, when the term cannot be parsed into years.
+func savingPlanRow(item retailprices.RetailPrice, plan retailprices.SavingsPlanPrice, sku schema.SKU, region schema.Region, providerID uint) (schema.SavingPlan, bool) {
+	years, err := convertData.ParseYears(plan.Term)
+	if err == nil && (years == nil || *years != math.Trunc(*years)) {
+		err = fmt.Errorf("not a whole number of years")
+	}
+	if err != nil {
+		recordIssue("saving_plans", item.SkuID, "lease_contract_length", plan.Term, err)
+		return schema.SavingPlan{}, false
+	}
+	length := int(*years)
+
+	skuID := sku.ID
+	return schema.SavingPlan{
+		DiscountedSku:          item.SkuID,
+		DiscountedSkuID:        &skuID,
+		PlanType:               "ComputeSavingsPlans",
+		PurchaseTerm:           fmt.Sprintf("%dyr", length),
+		Description:            fmt.Sprintf("%s savings plan for %s %s", plan.Term, item.ProductName, item.SkuName),
+		EffectiveDate:          item.EffectiveStartDate,
+		LeaseContractLength:    length,
+		LeaseContractUnit:      "year",
+		DiscountedRate:         plan.RetailPrice.String(), // Hourly rate under the plan
+		Currency:               item.CurrencyCode,
+		RateCode:               item.MeterID + ".SavingsPlan." + strings.ReplaceAll(plan.Term, " ", ""),
+		RegionID:               region.RegionID,
+		RegionCode:             region.RegionCode,
+		ProviderID:             providerID,
+		DiscountedInstanceType: item.ArmSkuName,
+		DiscountedUsageType:    item.MeterName,
+		DiscountedServiceCode:  item.ServiceName,
+		DiscountedRegionCode:   item.ArmRegionName,
+		Unit:                   item.UnitOfMeasure,
+	}, true
+}
+
+// recordIssue records a value of an Azure row that could not be parsed.
+func recordIssue(entity, key, field, raw string, err error) {
+	if err := quality.Record(config.DB, quality.Issue("Azure", entity, key, field, raw, err)); err != nil {
+		log.Printf("Error recording data quality issue: %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cco-package/fetcher/Azure/utils"
+	"cco-package/fetcher/convertData"
+)
+
+// ResourceSKU is a virtual machine size of the Compute resource SKUs API in
+// one location, with its capabilities.
+type ResourceSKU struct {
+	Name         string // e.g. "Standard_D2s_v3", matches armSkuName of the prices API
+	Location     string // e.g. "westeurope", matches armRegionName
+	Family       string // e.g. "standardDSv3Family"
+	Tier         string
+	Size         string
+	Capabilities Capabilities
+}
+
+// Capabilities are the capabilities Azure publishes for a VM size. The typed
+// fields are nil when Azure does not publish the capability for the size;
+// Raw holds every capability as published, including those not mapped here.
+type Capabilities struct {
+	VCPUs                         *int
+	VCPUsAvailable                *int
+	VCPUsPerCore                  *int
+	MemoryGB                      *float64
+	ACUs                          *int // Azure Compute Units, relative CPU performance
+	GPUs                          *int
+	CpuArchitectureType           string // "x64" or "Arm64"
+	HyperVGenerations             string // e.g. "V1,V2"
+	PremiumIO                     *bool  // Supports premium SSD disks
+	AcceleratedNetworking         *bool
+	EncryptionAtHost              *bool
+	EphemeralOSDisk               *bool
+	LowPriorityCapable            *bool
+	MaxDataDiskCount              *int
+	MaxNetworkInterfaces          *int
+	MaxResourceVolumeMB           string // Local temporary disk, kept raw for convertData.ParseResourceVolumeMB
+	UncachedDiskIOPS              *int64
+	UncachedDiskBytesPerSecond    *int64
+	CombinedTempDiskAndCachedIOPS *int64
+	Raw                           map[string]string
+}
+
+// skuKey identifies a resource SKU. Names and locations are compared in
+// lower case, since the two APIs do not always agree on the casing.
+type skuKey struct {
+	name     string
+	location string
+}
+
+// SkuIndex looks up resource SKUs by name and location.
+type SkuIndex map[skuKey]*ResourceSKU
+
+// Lookup returns the resource SKU of the VM size in the location, or nil when
+// the size is not offered there.
+func (index SkuIndex) Lookup(name, location string) *ResourceSKU {
+	return index[skuKey{strings.ToLower(name), strings.ToLower(location)}]
+}
+
+// FetchSkuIndex reads every virtual machine resource SKU of the subscription,
+// following nextLink, and indexes it by name and location.
+func FetchSkuIndex(subscriptionID, bearerToken string) (SkuIndex, error) {
+	index := make(SkuIndex)
+	url := fmt.Sprintf(
+		"https://management.azure.com/subscriptions/%s/providers/Microsoft.Compute/skus?api-version=2024-07-01",
+		subscriptionID,
+	)
+
+	for url != "" {
+		skuData, err := utils.FetchDataWithBearerToken(url, bearerToken)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching SKU data: %w", err)
+		}
+		skuItems, ok := skuData["value"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid format for SKU items")
+		}
+		for _, item := range skuItems {
+			if skuItem, ok := item.(map[string]interface{}); ok {
+				index.add(skuItem)
+			}
+		}
+		url, _ = safeString(skuData["nextLink"])
+	}
+	return index, nil
+}
+
+// add indexes one item of the resource SKUs API under each of its locations.
+func (index SkuIndex) add(item map[string]interface{}) {
+	if resourceType, _ := safeString(item["resourceType"]); resourceType != "virtualMachines" {
+		return
+	}
+	name, _ := safeString(item["name"])
+	family, _ := safeString(item["family"])
+	tier, _ := safeString(item["tier"])
+	size, _ := safeString(item["size"])
+	capabilities := parseCapabilities(item["capabilities"])
+
+	locations, _ := item["locations"].([]interface{})
+	for _, location := range locations {
+		location, ok := safeString(location)
+		if !ok || location == "" {
+			continue
+		}
+		index[skuKey{strings.ToLower(name), strings.ToLower(location)}] = &ResourceSKU{
+			Name:         name,
+			Location:     location,
+			Family:       family,
+			Tier:         tier,
+			Size:         size,
+			Capabilities: capabilities,
+		}
+	}
+}
+
+// parseCapabilities maps the name/value capability list of a resource SKU.
+// All values are strings in the API.
+func parseCapabilities(value interface{}) Capabilities {
+	c := Capabilities{Raw: make(map[string]string)}
+	list, _ := value.([]interface{})
+	for _, entry := range list {
+		capability, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := safeString(capability["name"])
+		raw, _ := safeString(capability["value"])
+		if name == "" {
+			continue
+		}
+		c.Raw[name] = raw
+
+		switch name {
+		case "vCPUs":
+			c.VCPUs = parseInt(raw)
+		case "vCPUsAvailable":
+			c.VCPUsAvailable = parseInt(raw)
+		case "vCPUsPerCore":
+			c.VCPUsPerCore = parseInt(raw)
+		case "MemoryGB":
+			// Invalid values are recorded by the importer, which parses them again
+			c.MemoryGB, _ = convertData.ParseMemoryGiB(raw)
+		case "ACUs":
+			c.ACUs = parseInt(raw)
+		case "GPUs":
+			c.GPUs = parseInt(raw)
+		case "CpuArchitectureType":
+			c.CpuArchitectureType = raw
+		case "HyperVGenerations":
+			c.HyperVGenerations = raw
+		case "PremiumIO":
+			c.PremiumIO = parseBool(raw)
+		case "AcceleratedNetworkingEnabled":
+			c.AcceleratedNetworking = parseBool(raw)
+		case "EncryptionAtHostSupported":
+			c.EncryptionAtHost = parseBool(raw)
+		case "EphemeralOSDiskSupported":
+			c.EphemeralOSDisk = parseBool(raw)
+		case "LowPriorityCapable":
+			c.LowPriorityCapable = parseBool(raw)
+		case "MaxDataDiskCount":
+			c.MaxDataDiskCount = parseInt(raw)
+		case "MaxNetworkInterfaces":
+			c.MaxNetworkInterfaces = parseInt(raw)
+		case "MaxResourceVolumeMB":
+			c.MaxResourceVolumeMB = raw
+		case "UncachedDiskIOPS":
+			c.UncachedDiskIOPS = parseInt64(raw)
+		case "UncachedDiskBytesPerSecond":
+			c.UncachedDiskBytesPerSecond = parseInt64(raw)
+		case "CombinedTempDiskAndCachedIOPS":
+			c.CombinedTempDiskAndCachedIOPS = parseInt64(raw)
+		}
+	}
+	return c
+}
+
+// JSON returns every capability as a JSON object, for the capabilities column.
+func (c Capabilities) JSON() *string {
+	if len(c.Raw) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(c.Raw)
+	if err != nil {
+		return nil
+	}
+	s := string(data)
+	return &s
+}
+
+func safeString(value interface{}) (string, bool) {
+	str, ok := value.(string)
+	return str, ok
+}
+
+func parseInt(value string) *int {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+func parseInt64(value string) *int64 {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+func parseBool(value string) *bool {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,12 @@
+package services
+
+import "cco-package/fetcher/Azure/retailprices"
+
+// retailClient reads the Azure Retail Prices API for all imports.
+var retailClient = retailprices.New()
+
+// vmPriceQuery selects the retail prices of Virtual Machines in USD.
+var vmPriceQuery = retailprices.Query{
+	Filter:   retailprices.NewFilter().ServiceName("Virtual Machines"),
+	Currency: "USD",
+}
@@ -1,19 +1,15 @@
 package utils
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io/ioutil"
+	"context"
 	"log"
-	"net/http"
 	"os"
+	"sync"
 
+	"cco-package/fetcher/Azure/credentials"
 	"github.com/joho/godotenv"
 )
 
-
 // logger is a package-level logger that defaults to log.Default().
 var logger *log.Logger = log.Default()
 
@@ -33,64 +29,31 @@ func LoadEnv() {
 	}
 }
 
-// GenerateBearerToken generates a bearer token for Azure API access.
-func GenerateBearerToken() (string, error) {
-	clientID := os.Getenv("AZURE_CLIENT_ID")
-	clientSecret := os.Getenv("AZURE_CLIENT_SECRET")
-	tenantID := os.Getenv("AZURE_TENANT_ID")
+// credential is the chain built from the environment on first use.
+var (
+	credentialMu sync.Mutex
+	credential   *credentials.Chain
+)
 
-	// Validate environment variables
-	if clientID == "" || clientSecret == "" || tenantID == "" {
-		missing := []string{}
-		if clientID == "" {
-			missing = append(missing, "AZURE_CLIENT_ID")
-		}
-		if clientSecret == "" {
-			missing = append(missing, "AZURE_CLIENT_SECRET")
-		}
-		if tenantID == "" {
-			missing = append(missing, "AZURE_TENANT_ID")
+// GenerateBearerToken returns a bearer token for Azure API access. The
+// credentials are read from the environment (see credentials.FromEnvironment)
+// and the token is cached until shortly before it expires.
+func GenerateBearerToken() (string, error) {
+	credentialMu.Lock()
+	if credential == nil {
+		chain, err := credentials.FromEnvironment()
+		if err != nil {
+			credentialMu.Unlock()
+			return "", err
 		}
-		return "", fmt.Errorf("missing required environment variables: %v", missing)
+		credential = chain
 	}
+	chain := credential
+	credentialMu.Unlock()
 
-	url := fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", tenantID)
-
-	payload := map[string]string{
-		"client_id":     clientID,
-		"client_secret": clientSecret,
-		"grant_type":    "client_credentials",
-		"scope":         "https://management.azure.com/.default",
-	}
-
-	// Construct the payload in x-www-form-urlencoded format
-	payloadBytes := []byte{}
-	for key, value := range payload {
-		payloadBytes = append(payloadBytes, []byte(fmt.Sprintf("%s=%s&", key, value))...)
-	}
-	// Remove the trailing '&'
-	payloadBytes = payloadBytes[:len(payloadBytes)-1]
-
-	resp, err := http.Post(url, "application/x-www-form-urlencoded", bytes.NewBuffer(payloadBytes))
+	token, err := chain.Token(context.Background(), credentials.ManagementScope)
 	if err != nil {
-		return "", fmt.Errorf("error making token request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return "", fmt.Errorf("non-200 response: %d, body: %s", resp.StatusCode, string(body))
-	}
-
-	var responseData map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&responseData); err != nil {
-		return "", fmt.Errorf("error decoding response: %w", err)
+		return "", err
 	}
-
-	token, ok := responseData["access_token"].(string)
-	if !ok {
-		return "", errors.New("access_token not found in response")
-	}
-
-	return token, nil
-}
\ No newline at end of file
+	return token.AccessToken, nil
+}
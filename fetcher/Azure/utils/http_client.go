@@ -12,32 +12,6 @@ func JSONResponse(c *gin.Context, code int, data interface{}) {
 	c.JSON(code, gin.H{"data": data})
 }
 
-// FetchData makes an HTTP GET request to the given URL and returns the response as a map
-func FetchData(url string) (map[string]interface{}, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching data: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("received non-200 response: %d, body: %s", resp.StatusCode, body)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
-	}
-
-	var data map[string]interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		return nil, fmt.Errorf("error unmarshaling JSON: %w\nResponse body: %s", err, body)
-	}
-
-	return data, nil
-}
-
 // FetchDataWithBearerToken fetches data from an authenticated API endpoint
 func FetchDataWithBearerToken(url, bearerToken string) (map[string]interface{}, error) {
 	req, err := http.NewRequest("GET", url, nil)
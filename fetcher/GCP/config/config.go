@@ -12,21 +12,25 @@ import (
 var DB *gorm.DB
 var AuthToken string
 
-func ConnectDatabase() {
+// ConnectDatabase opens the database and fetches a gcloud access token. It
+// returns an error rather than panicking, since GCP runs next to the other
+// providers and must not take them down with it.
+func ConnectDatabase() error {
 	// Set up DB connection
 	dsn := "host=localhost user=postgres password=password dbname=temp_db port=5432 sslmode=disable"
 	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		panic("Failed to connect to database!")
+		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 	DB = database
 
 	// Fetch access token using gcloud
 	token, err := getGcloudAccessToken()
 	if err != nil {
-		panic(fmt.Sprintf("Failed to get GCP access token: %v", err))
+		return fmt.Errorf("failed to get GCP access token: %v", err)
 	}
 	AuthToken = "Bearer " + token
+	return nil
 }
 
 func getGcloudAccessToken() (string, error) {
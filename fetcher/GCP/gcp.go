@@ -4,19 +4,30 @@ import (
 
 	"cco-package/fetcher/GCP/config"
 	"cco-package/fetcher/GCP/services"
+	"cco-package/fetcher/history"
 )
 
-func RunGCP() error {
-	// Connect to DB (you might want to add error handling if ConnectDatabase ever returns an error)
-	config.ConnectDatabase()
+// RunGCP fetches regions and SKUs from GCP. Both are recorded as steps of
+// run, which may be nil.
+func RunGCP(run *history.Run) error {
+	// Connect to DB and fetch the gcloud access token
+	if err := config.ConnectDatabase(); err != nil {
+		return err
+	}
 
 	// Step 1: Fetch and store regions
-	if err := services.FetchAndStoreRegions(); err != nil {
+	step := run.StartStep("regions", "Compute Engine", "")
+	err := services.FetchAndStoreRegions()
+	step.Finish(history.Rows{}, err)
+	if err != nil {
 		return fmt.Errorf("error syncing regions: %w", err)
 	}
 
 	// Step 2: Fetch and store SKUs
-	if err := services.FetchAndInsertSkus(); err != nil {
+	step = run.StartStep("skus", "Compute Engine", "")
+	err = services.FetchAndInsertSkus()
+	step.Finish(history.Rows{}, err)
+	if err != nil {
 		return fmt.Errorf("error syncing SKUs: %w", err)
 	}
 
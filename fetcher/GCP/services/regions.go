@@ -12,15 +12,17 @@ import (
 
 	"cco-package/fetcher/GCP/config"
 	"cco-package/fetcher/GCP/models"
+	"cco-package/fetcher/regions"
+	"cco-package/fetcher/schema"
 )
 
 
 func FetchAndStoreRegions() error {
 	// Step 1: Check or insert GCP provider
-	var provider models.Provider
+	var provider schema.Provider
 	err := config.DB.Where("provider_name = ?", "GCP").First(&provider).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		provider = models.Provider{
+		provider = schema.Provider{
 			ProviderName: "GCP",
 			CreatedDate:  time.Now(),
 			ModifiedDate: time.Now(),
@@ -44,10 +46,10 @@ func FetchAndStoreRegions() error {
 
 	// Step 3: Insert or update regions
 	for _, item := range regionList.Items {
-		var existing models.Region
-		err := config.DB.Where("region_code = ?", item.Name).First(&existing).Error
+		var existing schema.Region
+		err := config.DB.Where("provider_id = ? AND region_code = ?", provider.ProviderID, item.Name).First(&existing).Error
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			newRegion := models.Region{
+			newRegion := schema.Region{
 				RegionCode:   item.Name,
 				ProviderID:   provider.ProviderID,
 				CreatedDate:  time.Now(),
@@ -56,14 +58,22 @@ func FetchAndStoreRegions() error {
 			}
 			if err := config.DB.Create(&newRegion).Error; err != nil {
 				fmt.Printf("Failed to insert region %s: %v\n", item.Name, err)
-			} else {
-				fmt.Printf("Inserted region: %s\n", item.Name)
+				continue
 			}
+			fmt.Printf("Inserted region: %s\n", item.Name)
+			existing = newRegion
 		} else if err != nil {
 			fmt.Printf("Error checking region %s: %v\n", item.Name, err)
+			continue
 		} else {
 			fmt.Printf("Region already exists: %s\n", item.Name)
 		}
+
+		// Refresh the catalogue fields from the region description
+		info := regions.GCP(item.Name, item.Description)
+		if err := config.DB.Model(&existing).Updates(info.Columns()).Error; err != nil {
+			fmt.Printf("Failed to update region %s: %v\n", item.Name, err)
+		}
 	}
 
 	return nil
@@ -8,7 +8,7 @@ import (
 	"time"
 
 	"cco-package/fetcher/GCP/config"
-	"cco-package/fetcher/GCP/models"
+	"cco-package/fetcher/schema"
 )
 
 type SkuResponse struct {
@@ -26,6 +26,12 @@ type SkuCategory struct {
 	UsageType      string `json:"usageType"`
 }
 
+// FetchAndInsertSkus stores the Compute Engine SKUs of the Cloud Billing
+// catalog. Catalog SKUs are billing meters such as "N1 Predefined Instance
+// Core" or "E2 Instance Ram" and carry no memory, network, storage or lease
+// attributes, so unlike AWS and Azure SKUs they have nothing for the
+// convertData parsers to parse. The typed hardware columns of GCP SKUs stay
+// NULL until machine types are read from the Compute Engine API.
 func FetchAndInsertSkus() error {
 	url := "https://cloudbilling.googleapis.com/v1/services/6F81-5844-456A/skus"
 
@@ -52,35 +58,34 @@ func FetchAndInsertSkus() error {
 		return fmt.Errorf("JSON unmarshal error: %w\nRaw body: %s", err, string(body))
 	}
 
+	// Lookup the GCP provider; region codes are only unique per provider
+	var provider schema.Provider
+	if err := config.DB.Where("provider_name = ?", "GCP").First(&provider).Error; err != nil {
+		return fmt.Errorf("provider GCP not found: %w", err)
+	}
+
 	for _, sku := range skuResp.Skus {
 		if len(sku.ServiceRegions) == 0 {
 			continue
 		}
 		regionCode := sku.ServiceRegions[0]
 
-		// Lookup region by region_code
-		var region models.Region
-		if err := config.DB.Where("region_code = ?", regionCode).First(&region).Error; err != nil {
+		// Lookup region by provider and region_code
+		var region schema.Region
+		if err := config.DB.Where("provider_id = ? AND region_code = ?", provider.ProviderID, regionCode).First(&region).Error; err != nil {
 			fmt.Printf("❌ Region not found in DB: %s\n", regionCode)
 			continue
 		}
 
-		// Lookup provider using region.ProviderID
-		var provider models.Provider
-		if err := config.DB.Where("provider_id = ?", region.ProviderID).First(&provider).Error; err != nil {
-			fmt.Printf("❌ Provider not found for region %s\n", regionCode)
-			continue
-		}
-
-		// Check if SKU already exists
-		var existing models.SKU
-		if err := config.DB.Where("sku_code = ?", sku.SkuID).First(&existing).Error; err == nil {
+		// Check if SKU already exists in this provider and region
+		var existing schema.SKU
+		if err := config.DB.Where("provider_id = ? AND region_id = ? AND sku_code = ?", provider.ProviderID, region.RegionID, sku.SkuID).First(&existing).Error; err == nil {
 			fmt.Printf("⚠️ SKU already exists: %s\n", sku.SkuID)
 			continue
 		}
 
 		// Insert new SKU
-		newSKU := models.SKU{
+		newSKU := schema.SKU{
 			ProviderID:    provider.ProviderID,
 			RegionID:      region.RegionID,
 			RegionCode:    region.RegionCode,
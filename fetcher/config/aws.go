@@ -0,0 +1,47 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultAWSServices are the offer codes ingested when AWS_SERVICES is not set.
+var DefaultAWSServices = []string{"AmazonEC2", "AmazonRDS", "AmazonElastiCache", "AWSLambda"}
+
+// DefaultRegionWorkers is the number of AWS regions processed in parallel
+// when AWS_REGION_WORKERS is not set.
+const DefaultRegionWorkers = 4
+
+// RegionWorkers returns the number of AWS regions to process in parallel,
+// read from the AWS_REGION_WORKERS environment variable.
+func RegionWorkers() int {
+	value := os.Getenv("AWS_REGION_WORKERS")
+	if value == "" {
+		return DefaultRegionWorkers
+	}
+	workers, err := strconv.Atoi(value)
+	if err != nil || workers < 1 {
+		log.Printf("Invalid AWS_REGION_WORKERS %q, using %d", value, DefaultRegionWorkers)
+		return DefaultRegionWorkers
+	}
+	return workers
+}
+
+// AWSServices returns the offer codes to ingest, read as a comma separated
+// list from the AWS_SERVICES environment variable.
+func AWSServices() []string {
+	value := os.Getenv("AWS_SERVICES")
+	if value == "" {
+		return DefaultAWSServices
+	}
+
+	var services []string
+	for _, service := range strings.Split(value, ",") {
+		if service = strings.TrimSpace(service); service != "" {
+			services = append(services, service)
+		}
+	}
+	return services
+}
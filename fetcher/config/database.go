@@ -11,9 +11,11 @@ import (
 // API Links
 const (
     BaseURL         = "https://pricing.us-east-1.amazonaws.com"
-    RegionURL       = "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonEC2/current/region_index.json"
+    OfferIndexURL   = "https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/index.json"
     SavingRegionURL = "https://pricing.us-east-1.amazonaws.com/savingsPlan/v1.0/aws/AWSComputeSavingsPlan/current/region_index.json"
     DbConnStr       = "host=localhost user=postgres password=password dbname=temp_db sslmode=disable"
+    MainDbConnStr   = "host=localhost user=postgres password=password dbname=main_db port=5432 sslmode=disable"
+    BackupDbConnStr = "host=localhost user=postgres password=password dbname=backup_db port=5432 sslmode=disable"
     PriceListPath   = "./price-list"
 )
 
@@ -31,10 +33,21 @@ func ConnectDatabase() error {
     return nil // Return nil if the connection is successful
 }
 
-// InitializeLogger initializes the logger and clears the logfile on every run.
+// EnsureDatabase connects to the database unless a connection is already
+// open, so the providers that Fetcher runs side by side share its connection
+// instead of replacing DB under each other.
+func EnsureDatabase() error {
+	if DB != nil {
+		return nil
+	}
+	return ConnectDatabase()
+}
+
+// InitializeLogger initializes the logger and appends to the logfile, so the
+// logs of earlier runs are kept.
 func InitializeLogger() (*log.Logger, error) {
-	// Use os.Create to truncate the file or create a new one if it doesn't exist.
-	file, err := os.Create("logfile.log")
+	// Open the file for appending, creating it if it doesn't exist.
+	file, err := os.OpenFile("logfile.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,213 @@
+// Package convertData parses the free-text hardware and term attributes of
+// the providers into numbers. All parsers return nil for a value that is
+// missing, and an error for a value that is present but cannot be parsed,
+// so callers can store NULL and record the raw value as a data-quality issue.
+package convertData
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// quantityPattern matches an optional "Nx" multiplier, a number that may
+// contain thousands separators and an optional unit, e.g. "4x 100 Gigabit",
+// "1,952 GiB" or "3yr".
+var quantityPattern = regexp.MustCompile(`^(?:(\d+)\s*x\s+)?(\d[\d,]*(?:\.\d+)?|\.\d+)\s*([a-z]*)$`)
+
+// missingValues are placeholders the providers use for an attribute that
+// does not apply.
+var missingValues = map[string]bool{"": true, "na": true, "n/a": true, "none": true, "-": true}
+
+// qualitativeNetwork are the AWS network performance levels of older
+// instance types. They are valid values without a published bandwidth.
+var qualitativeNetwork = map[string]bool{
+	"very low": true, "low": true, "low to moderate": true, "moderate": true, "high": true,
+}
+
+// memoryUnits converts a memory unit to GiB. Providers label GiB values as
+// "GB" (Azure's MemoryGB capability for example), so GB is read as GiB.
+var memoryUnits = map[string]float64{
+	"": 1, "gib": 1, "gb": 1,
+	"mib": 1.0 / 1024, "mb": 1.0 / 1024,
+	"tib": 1024, "tb": 1024,
+}
+
+// networkUnits converts a bandwidth unit to Mbps.
+var networkUnits = map[string]float64{
+	"gigabit": 1000, "gbps": 1000, "gb": 1000, "gbit": 1000,
+	"megabit": 1, "mbps": 1, "mb": 1, "mbit": 1,
+}
+
+// yearUnits converts a lease length unit to years.
+var yearUnits = map[string]float64{
+	"yr": 1, "yrs": 1, "year": 1, "years": 1, "y": 1,
+	"month": 1.0 / 12, "months": 1.0 / 12, "mo": 1.0 / 12,
+}
+
+// normalize trims and lower-cases a value.
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// parseQuantity parses value as a number followed by one of the given units.
+func parseQuantity(value string, units map[string]float64) (float64, error) {
+	matches := quantityPattern.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, fmt.Errorf("%q is not a number with a unit", value)
+	}
+	factor, ok := units[matches[3]]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q in %q", matches[3], value)
+	}
+	num, err := strconv.ParseFloat(strings.ReplaceAll(matches[2], ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number in %q: %v", value, err)
+	}
+	if matches[1] != "" {
+		count, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid multiplier in %q: %v", value, err)
+		}
+		num *= count
+	}
+	return num * factor, nil
+}
+
+// ParseMemoryGiB parses a memory size such as "16 GiB", "0.5 GiB" or "1,952 GiB".
+// A bare number is taken to be GiB.
+func ParseMemoryGiB(value string) (*float64, error) {
+	value = normalize(value)
+	if missingValues[value] {
+		return nil, nil
+	}
+	gib, err := parseQuantity(value, memoryUnits)
+	if err != nil {
+		return nil, err
+	}
+	return &gib, nil
+}
+
+// ParseNetworkMbps parses a network performance value into its baseline and
+// burst bandwidth in Mbps. "Up to 10 Gigabit" only guarantees the burst, so
+// the baseline is nil; "25 Gigabit" is both. Qualitative levels such as
+// "Moderate" have no published bandwidth and return nil for both.
+func ParseNetworkMbps(value string) (baseline, burst *float64, err error) {
+	value = normalize(value)
+	if missingValues[value] || qualitativeNetwork[value] {
+		return nil, nil, nil
+	}
+
+	upTo := strings.HasPrefix(value, "up to ")
+	mbps, err := parseQuantity(strings.TrimPrefix(value, "up to "), networkUnits)
+	if err != nil {
+		return nil, nil, err
+	}
+	if upTo {
+		return nil, &mbps, nil
+	}
+	return &mbps, &mbps, nil
+}
+
+// ParseYears parses a lease length such as "1yr", "3 Years" or "36 Months"
+// into years.
+func ParseYears(value string) (*float64, error) {
+	value = normalize(value)
+	if missingValues[value] {
+		return nil, nil
+	}
+	years, err := parseQuantity(value, yearUnits)
+	if err != nil {
+		return nil, err
+	}
+	return &years, nil
+}
+
+// storagePattern matches AWS instance storage such as "2 x 900 NVMe SSD",
+// "1 x 1.9 TB SSD" or "4 x 840". The size is in GB unless a unit is given.
+var storagePattern = regexp.MustCompile(`^(\d+)\s*x\s*(\d[\d,]*(?:\.\d+)?)\s*(gb|tb)?\s*(nvme ssd|ssd|hdd)?$`)
+
+// Storage media types of local instance storage.
+const (
+	MediaNVMeSSD = "NVMe SSD"
+	MediaSSD     = "SSD"
+	MediaHDD     = "HDD"
+)
+
+var storageMedia = map[string]string{"nvme ssd": MediaNVMeSSD, "ssd": MediaSSD, "hdd": MediaHDD}
+
+// Storage is the local instance storage of a SKU.
+type Storage struct {
+	EBSOnly      bool     // No local storage, only network attached volumes
+	DiskCount    *int     // Number of local disks
+	DiskSizeGB   *float64 // Size of each local disk in decimal GB (10^9 bytes)
+	TotalLocalGB *float64 // DiskCount * DiskSizeGB
+	MediaType    string   // MediaNVMeSSD, MediaSSD, MediaHDD or empty when not published
+}
+
+// ParseStorage parses the AWS "storage" attribute, e.g. "EBS only" or
+// "2 x 900 NVMe SSD".
+func ParseStorage(value string) (*Storage, error) {
+	value = normalize(value)
+	if missingValues[value] {
+		return nil, nil
+	}
+	if value == "ebs only" {
+		return noLocalStorage(), nil
+	}
+
+	matches := storagePattern.FindStringSubmatch(value)
+	if matches == nil {
+		return nil, fmt.Errorf("%q is not a disk count and size", value)
+	}
+	count, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid disk count in %q: %v", value, err)
+	}
+	size, err := strconv.ParseFloat(strings.ReplaceAll(matches[2], ",", ""), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid disk size in %q: %v", value, err)
+	}
+	if matches[3] == "tb" {
+		size *= 1000
+	}
+	return localStorage(count, size, storageMedia[matches[4]]), nil
+}
+
+// ParseResourceVolumeMB parses Azure's MaxResourceVolumeMB capability, the
+// size of the local temporary disk. Despite its name the value is in MiB
+// (2^20 bytes); it is converted to decimal GB, the unit AWS publishes disk
+// sizes in, so both providers share the disk columns. A size of 0 means the
+// VM has no local disk, which is stored like AWS' "EBS only".
+func ParseResourceVolumeMB(value string) (*Storage, error) {
+	value = normalize(value)
+	if missingValues[value] {
+		return nil, nil
+	}
+	mib, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a size in MB", value)
+	}
+	if mib == 0 {
+		return noLocalStorage(), nil
+	}
+	return localStorage(1, mib*(1<<20)/1e9, ""), nil
+}
+
+// noLocalStorage has no disks and a total of 0, so range filters on the
+// total also match SKUs without local storage.
+func noLocalStorage() *Storage {
+	count, total := 0, 0.0
+	return &Storage{EBSOnly: true, DiskCount: &count, TotalLocalGB: &total}
+}
+
+func localStorage(count int, sizeGB float64, media string) *Storage {
+	total := float64(count) * sizeGB
+	return &Storage{
+		DiskCount:    &count,
+		DiskSizeGB:   &sizeGB,
+		TotalLocalGB: &total,
+		MediaType:    media,
+	}
+}
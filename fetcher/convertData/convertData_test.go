@@ -0,0 +1,183 @@
+package convertData
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// num returns a pointer to v, for the expected values of the tables below.
+func num(v float64) *float64 {
+	return &v
+}
+
+// format prints an optional number for test messages.
+func format(v *float64) string {
+	if v == nil {
+		return "nil"
+	}
+	return fmt.Sprint(*v)
+}
+
+// sameNumber reports whether two optional numbers are both nil or equal up
+// to rounding.
+func sameNumber(got, want *float64) bool {
+	if got == nil || want == nil {
+		return got == nil && want == nil
+	}
+	return math.Abs(*got-*want) < 1e-9
+}
+
+func TestParseMemoryGiB(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *float64
+		wantErr bool
+	}{
+		{"16 GiB", num(16), false},
+		{"0.5 GiB", num(0.5), false},
+		{"1,952 GiB", num(1952), false},
+		{" 8 gib ", num(8), false},
+		{"8", num(8), false},
+		{"3.5 GB", num(3.5), false}, // Azure's MemoryGB is GiB
+		{"512 MiB", num(0.5), false},
+		{"2 TiB", num(2048), false},
+		{"", nil, false},
+		{"NA", nil, false},
+		{"lots", nil, true},
+		{"16 PB", nil, true},
+		{"GiB", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMemoryGiB(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMemoryGiB(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !sameNumber(got, tt.want) {
+			t.Errorf("ParseMemoryGiB(%q) = %s, want %s", tt.value, format(got), format(tt.want))
+		}
+	}
+}
+
+func TestParseNetworkMbps(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantBaseline *float64
+		wantBurst    *float64
+		wantErr      bool
+	}{
+		{"25 Gigabit", num(25000), num(25000), false},
+		{"Up to 10 Gigabit", nil, num(10000), false},
+		{"up to 12.5 gigabit", nil, num(12500), false},
+		{"4x 100 Gigabit", num(400000), num(400000), false},
+		{"100 Megabit", num(100), num(100), false},
+		{"50 Gbps", num(50000), num(50000), false},
+		{"Moderate", nil, nil, false},
+		{"Low to Moderate", nil, nil, false},
+		{"", nil, nil, false},
+		{"N/A", nil, nil, false},
+		{"Fast", nil, nil, true},
+		{"Up to 5 Parsecs", nil, nil, true},
+	}
+	for _, tt := range tests {
+		baseline, burst, err := ParseNetworkMbps(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseNetworkMbps(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !sameNumber(baseline, tt.wantBaseline) || !sameNumber(burst, tt.wantBurst) {
+			t.Errorf("ParseNetworkMbps(%q) = %s, %s, want %s, %s", tt.value,
+				format(baseline), format(burst), format(tt.wantBaseline), format(tt.wantBurst))
+		}
+	}
+}
+
+func TestParseYears(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    *float64
+		wantErr bool
+	}{
+		{"1yr", num(1), false},
+		{"3yr", num(3), false},
+		{"3 Years", num(3), false},
+		{"1 Year", num(1), false},
+		{"36 Months", num(3), false},
+		{"6 months", num(0.5), false},
+		{"", nil, false},
+		{"forever", nil, true},
+		{"1 decade", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseYears(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseYears(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !sameNumber(got, tt.want) {
+			t.Errorf("ParseYears(%q) = %s, want %s", tt.value, format(got), format(tt.want))
+		}
+	}
+}
+
+// storageCase is the expected outcome of a storage parser.
+type storageCase struct {
+	value     string
+	want      *Storage
+	wantErr   bool
+	wantCount int
+}
+
+func checkStorage(t *testing.T, parser string, tt storageCase, got *Storage, err error) {
+	t.Helper()
+	if (err != nil) != tt.wantErr {
+		t.Errorf("%s(%q) error = %v, wantErr %v", parser, tt.value, err, tt.wantErr)
+		return
+	}
+	if got == nil || tt.want == nil {
+		if got != tt.want {
+			t.Errorf("%s(%q) = %+v, want %+v", parser, tt.value, got, tt.want)
+		}
+		return
+	}
+	if got.EBSOnly != tt.want.EBSOnly || got.MediaType != tt.want.MediaType ||
+		got.DiskCount == nil || *got.DiskCount != tt.wantCount ||
+		!sameNumber(got.DiskSizeGB, tt.want.DiskSizeGB) || !sameNumber(got.TotalLocalGB, tt.want.TotalLocalGB) {
+		t.Errorf("%s(%q) = {EBSOnly:%v DiskCount:%v DiskSizeGB:%s TotalLocalGB:%s MediaType:%q}, want {EBSOnly:%v DiskCount:%d DiskSizeGB:%s TotalLocalGB:%s MediaType:%q}",
+			parser, tt.value, got.EBSOnly, *got.DiskCount, format(got.DiskSizeGB), format(got.TotalLocalGB), got.MediaType,
+			tt.want.EBSOnly, tt.wantCount, format(tt.want.DiskSizeGB), format(tt.want.TotalLocalGB), tt.want.MediaType)
+	}
+}
+
+func TestParseStorage(t *testing.T) {
+	tests := []storageCase{
+		{value: "EBS only", want: &Storage{EBSOnly: true, TotalLocalGB: num(0)}, wantCount: 0},
+		{value: "2 x 900 NVMe SSD", want: &Storage{DiskSizeGB: num(900), TotalLocalGB: num(1800), MediaType: MediaNVMeSSD}, wantCount: 2},
+		{value: "1 x 1.9 TB SSD", want: &Storage{DiskSizeGB: num(1900), TotalLocalGB: num(1900), MediaType: MediaSSD}, wantCount: 1},
+		{value: "24 x 13,980 HDD", want: &Storage{DiskSizeGB: num(13980), TotalLocalGB: num(335520), MediaType: MediaHDD}, wantCount: 24},
+		{value: "4 x 840", want: &Storage{DiskSizeGB: num(840), TotalLocalGB: num(3360)}, wantCount: 4},
+		{value: "", want: nil},
+		{value: "lots of disks", wantErr: true},
+		{value: "2 x 900 Tape", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseStorage(tt.value)
+		checkStorage(t, "ParseStorage", tt, got, err)
+	}
+}
+
+func TestParseResourceVolumeMB(t *testing.T) {
+	tests := []storageCase{
+		{value: "0", want: &Storage{EBSOnly: true, TotalLocalGB: num(0)}, wantCount: 0},
+		// MaxResourceVolumeMB is in MiB: 16384 MiB is 16 GiB, 17.18 decimal GB
+		{value: "16384", want: &Storage{DiskSizeGB: num(17.179869184), TotalLocalGB: num(17.179869184)}, wantCount: 1},
+		{value: "76800", want: &Storage{DiskSizeGB: num(80.5306368), TotalLocalGB: num(80.5306368)}, wantCount: 1},
+		{value: "", want: nil},
+		{value: "16 GiB", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseResourceVolumeMB(tt.value)
+		checkStorage(t, "ParseResourceVolumeMB", tt, got, err)
+	}
+}
@@ -1,12 +1,16 @@
 package fetcher
 
 import (
-	 "cco-package/fetcher/AWS"
-	//"cco-package/fetcher/Azure"
+	"cco-package/fetcher/AWS"
+	"cco-package/fetcher/Azure"
+	"cco-package/fetcher/GCP"
 	"cco-package/fetcher/config"
-	//"cco-package/fetcher/GCP"
+	"cco-package/fetcher/history"
+	"cco-package/fetcher/migrations"
 	"log"
 	"sync"
+
+	"gorm.io/gorm"
 )
 
 // logger is a package-level variable that can be set from main.
@@ -23,7 +27,8 @@ func DoSomething(l *log.Logger) {
 	// Your additional code here...
 }
 
-// Fetcher runs the AWS and Azure tasks concurrently and returns the first error (if any).
+// Fetcher runs the AWS, Azure and GCP tasks concurrently and returns the first
+// error (if any). Every provider run is recorded in ingestion_runs.
 func Fetcher() error {
 	if logger != nil {
 		logger.Println("Starting Fetcher")
@@ -33,35 +38,41 @@ func Fetcher() error {
 	if err := config.ConnectDatabase(); err != nil {
 		return err
 	}
+	db := config.DB
+
+	// Apply the pending schema migrations to temp_db.
+	if err := migrations.Up(db); err != nil {
+		return err
+	}
 
-	// Use a WaitGroup to run AWS and Azure concurrently.
+	// Use a WaitGroup to run AWS, Azure and GCP concurrently.
 	var wg sync.WaitGroup
-	wg.Add(2)
+	wg.Add(3)
 
-	errChan := make(chan error, 2) // Buffered channel to collect errors
+	errChan := make(chan error, 3) // Buffered channel to collect errors
 
 	go func() {
 		defer wg.Done()
-		if err := AWS.RunAWS(); err != nil {
+		if err := recordRun(db, "AWS", AWS.RunAWS); err != nil {
 			errChan <- err
 		}
 	}()
 
-	// go func(){
-	// 	defer wg.Done()
-	// 	if err := GCP.RunGCP(); err != nil {
-	// 		errChan <- err
-	// 	}
-	// }()
-
-	// go func() {
-	// 	defer wg.Done()
-	// 	if err := Azure.RunAzure(); err != nil {
-	// 		errChan <- err
-	// 	}
-	// }()
-
-	// Wait for both goroutines to complete.
+	go func() {
+		defer wg.Done()
+		if err := recordRun(db, "GCP", GCP.RunGCP); err != nil {
+			errChan <- err
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		if err := recordRun(db, "Azure", Azure.RunAzure); err != nil {
+			errChan <- err
+		}
+	}()
+
+	// Wait for all goroutines to complete.
 	wg.Wait()
 	close(errChan)
 
@@ -73,10 +84,39 @@ func Fetcher() error {
 	}
 
 	if logger != nil {
-		logger.Println("AWS and Azure tasks completed successfully!")
+		logger.Println("AWS, Azure and GCP tasks completed successfully!")
 	} else {
-		println("AWS and Azure tasks completed successfully!")
+		println("AWS, Azure and GCP tasks completed successfully!")
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// FetchSavingsPlans ingests only the AWS savings plans into temp_db, without
+// downloading the offer files of the other services. It is recorded as an AWS
+// run of its own.
+func FetchSavingsPlans() error {
+	if err := config.ConnectDatabase(); err != nil {
+		return err
+	}
+	db := config.DB
+
+	if err := migrations.Up(db); err != nil {
+		return err
+	}
+	return recordRun(db, "AWS", AWS.RunSavingsPlans)
+}
+
+// recordRun runs a provider runner inside an ingestion_runs row, which is
+// finished with the runner's error. A failure to record the run is logged and
+// does not stop the runner.
+func recordRun(db *gorm.DB, provider string, runner func(*history.Run) error) error {
+	run, err := history.Start(db, provider)
+	if err != nil {
+		log.Printf("Failed to record %s run: %v", provider, err)
+	}
+
+	err = runner(run)
+	run.Finish(err)
+	return err
+}
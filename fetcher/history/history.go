@@ -0,0 +1,204 @@
+package history
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Run and step statuses.
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+)
+
+// IngestionRun is one run of a provider fetcher.
+type IngestionRun struct {
+	ID           uint      `gorm:"primaryKey"`
+	RunID        string    `gorm:"size:32;uniqueIndex"`
+	Provider     string    `gorm:"size:50;index"`
+	Status       string    `gorm:"size:20"`
+	StartedAt    time.Time `gorm:"index"`
+	FinishedAt   *time.Time
+	RowsInserted int64
+	RowsUpdated  int64
+	RowsDisabled int64
+	Error        string `gorm:"type:text"`
+}
+
+// IngestionRunStep is one unit of work of a run, usually a service in a region.
+type IngestionRunStep struct {
+	ID           uint   `gorm:"primaryKey"`
+	RunID        string `gorm:"size:32;index"`
+	Provider     string `gorm:"size:50"`
+	Step         string `gorm:"size:50"`
+	Service      string `gorm:"size:100"`
+	Region       string `gorm:"size:100"`
+	Status       string `gorm:"size:20"`
+	StartedAt    time.Time
+	FinishedAt   *time.Time
+	RowsInserted int64
+	RowsUpdated  int64
+	RowsDisabled int64  // Rows of a previous version that were removed or disabled
+	Error        string `gorm:"type:text"`
+}
+
+// Rows counts the rows a run or step changed.
+type Rows struct {
+	Inserted int64
+	Updated  int64
+	Disabled int64
+}
+
+// Run records a provider run and its steps. A nil *Run is valid and records
+// nothing, so the provider runners can also be used on their own.
+type Run struct {
+	db     *gorm.DB
+	record IngestionRun
+}
+
+// Step records one step of a run. A nil *Step is valid and records nothing.
+type Step struct {
+	db     *gorm.DB
+	record IngestionRunStep
+}
+
+// Start inserts a running ingestion_runs row for the provider.
+func Start(db *gorm.DB, provider string) (*Run, error) {
+	run := &Run{
+		db: db,
+		record: IngestionRun{
+			RunID:     newRunID(),
+			Provider:  provider,
+			Status:    StatusRunning,
+			StartedAt: time.Now(),
+		},
+	}
+	if err := db.Create(&run.record).Error; err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// ID returns the run ID, or an empty string for a nil run.
+func (r *Run) ID() string {
+	if r == nil {
+		return ""
+	}
+	return r.record.RunID
+}
+
+// Finish stores the end time, the totals of the steps and the error, if any.
+func (r *Run) Finish(runErr error) {
+	if r == nil {
+		return
+	}
+
+	now := time.Now()
+	r.record.FinishedAt = &now
+	r.record.Status = StatusSucceeded
+	if runErr != nil {
+		r.record.Status = StatusFailed
+		r.record.Error = runErr.Error()
+	}
+
+	var totals Rows
+	err := r.db.Model(&IngestionRunStep{}).
+		Select("COALESCE(SUM(rows_inserted), 0) AS inserted, COALESCE(SUM(rows_updated), 0) AS updated, COALESCE(SUM(rows_disabled), 0) AS disabled").
+		Where("run_id = ?", r.record.RunID).
+		Scan(&totals).Error
+	if err != nil {
+		log.Printf("Failed to sum steps of run %s: %v", r.record.RunID, err)
+	}
+	r.record.RowsInserted = totals.Inserted
+	r.record.RowsUpdated = totals.Updated
+	r.record.RowsDisabled = totals.Disabled
+
+	if err := r.db.Save(&r.record).Error; err != nil {
+		log.Printf("Failed to record end of run %s: %v", r.record.RunID, err)
+	}
+}
+
+// StartStep inserts a running ingestion_run_steps row. Errors are logged
+// rather than returned, since history must never stop an ingestion.
+func (r *Run) StartStep(step, service, region string) *Step {
+	if r == nil {
+		return nil
+	}
+
+	s := &Step{
+		db: r.db,
+		record: IngestionRunStep{
+			RunID:     r.record.RunID,
+			Provider:  r.record.Provider,
+			Step:      step,
+			Service:   service,
+			Region:    region,
+			Status:    StatusRunning,
+			StartedAt: time.Now(),
+		},
+	}
+	if err := r.db.Create(&s.record).Error; err != nil {
+		log.Printf("Failed to record step %s of run %s: %v", step, r.record.RunID, err)
+	}
+	return s
+}
+
+// Finish stores the end time, row counts and error of the step.
+func (s *Step) Finish(rows Rows, stepErr error) {
+	s.finish(StatusSucceeded, rows, stepErr)
+}
+
+// Skip marks the step as skipped, e.g. because its data is unchanged.
+func (s *Step) Skip() {
+	s.finish(StatusSkipped, Rows{}, nil)
+}
+
+func (s *Step) finish(status string, rows Rows, stepErr error) {
+	if s == nil {
+		return
+	}
+
+	now := time.Now()
+	s.record.FinishedAt = &now
+	s.record.Status = status
+	s.record.RowsInserted = rows.Inserted
+	s.record.RowsUpdated = rows.Updated
+	s.record.RowsDisabled = rows.Disabled
+	if stepErr != nil {
+		s.record.Status = StatusFailed
+		s.record.Error = stepErr.Error()
+	}
+
+	if err := s.db.Save(&s.record).Error; err != nil {
+		log.Printf("Failed to record end of step %s of run %s: %v", s.record.Step, s.record.RunID, err)
+	}
+}
+
+// newRunID returns a random 32 character hex ID.
+func newRunID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// RunsBetween returns the runs started in [from, to), oldest first.
+func RunsBetween(db *gorm.DB, from, to time.Time) ([]IngestionRun, error) {
+	var runs []IngestionRun
+	err := db.Where("started_at >= ? AND started_at < ?", from, to).Order("started_at").Find(&runs).Error
+	return runs, err
+}
+
+// Steps returns the steps of a run in the order they started.
+func Steps(db *gorm.DB, runID string) ([]IngestionRunStep, error) {
+	var steps []IngestionRunStep
+	err := db.Where("run_id = ?", runID).Order("started_at, id").Find(&steps).Error
+	return steps, err
+}
@@ -0,0 +1,215 @@
+// Package migrations applies the numbered SQL migrations embedded from sql/
+// and records them in the schema_migrations table. Migration N is the pair
+// sql/NNNN_<name>.up.sql and sql/NNNN_<name>.down.sql. Temp, main and backup
+// databases are migrated with the same files, so they always have the same
+// shape at the same version.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey is the advisory lock held while migrating, so two processes never
+// apply the same migration at once.
+const lockKey = 20250601
+
+// Migration is one numbered schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load returns the embedded migrations ordered by version.
+func Load() ([]Migration, error) {
+	return load(files)
+}
+
+// load reads the migrations in the sql directory of fsys. Every version from
+// 1 up needs exactly one up and one down script.
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s is neither .up.sql nor .down.sql", name)
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		number, label, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s does not start with a version number", name)
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join("sql", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %v", name, err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, m.Name, label)
+		}
+		script := &m.Down
+		if direction == "up" {
+			script = &m.Up
+		}
+		if *script != "" {
+			return nil, fmt.Errorf("migration %d has more than one %s script", version, direction)
+		}
+		*script = string(body)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// Latest returns the version of the newest embedded migration.
+func Latest() (int, error) {
+	migrations, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// Version returns the schema version of the database, 0 when no migration
+// was applied yet.
+func Version(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable("schema_migrations") {
+		return 0, nil
+	}
+	var version int
+	if err := db.Raw("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %v", err)
+	}
+	return version, nil
+}
+
+// Up applies the pending migrations in one transaction, so a failing
+// migration leaves the database at its previous version.
+func Up(db *gorm.DB) error {
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		current, err := lock(tx)
+		if err != nil {
+			return err
+		}
+		if len(migrations) > 0 && current > migrations[len(migrations)-1].Version {
+			return fmt.Errorf("database is at schema version %d, newer than the %d this build knows", current, migrations[len(migrations)-1].Version)
+		}
+
+		for _, m := range migrations {
+			if m.Version <= current {
+				continue
+			}
+			if step := prepare[m.Version]; step != nil {
+				if err := step(tx); err != nil {
+					return err
+				}
+			}
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %v", m.Version, m.Name, err)
+			}
+			if err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name).Error; err != nil {
+				return fmt.Errorf("failed to record migration %d_%s: %v", m.Version, m.Name, err)
+			}
+			fmt.Printf("Applied migration %d_%s\n", m.Version, m.Name)
+		}
+		return nil
+	})
+}
+
+// Down reverts the last steps applied migrations in one transaction.
+func Down(db *gorm.DB, steps int) error {
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		current, err := lock(tx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			m := migrations[i]
+			if m.Version > current {
+				continue
+			}
+			if err := tx.Exec(m.Down).Error; err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %v", m.Version, m.Name, err)
+			}
+			if err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version).Error; err != nil {
+				return fmt.Errorf("failed to record revert of migration %d_%s: %v", m.Version, m.Name, err)
+			}
+			fmt.Printf("Reverted migration %d_%s\n", m.Version, m.Name)
+			steps--
+		}
+		return nil
+	})
+}
+
+// lock takes the migration lock for the rest of the transaction, creates the
+// schema_migrations table and returns the current version.
+func lock(tx *gorm.DB) (int, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+		return 0, fmt.Errorf("failed to lock schema_migrations: %v", err)
+	}
+	err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    bigint PRIMARY KEY,
+		name       varchar(255) NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT current_timestamp
+	)`).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+	return Version(tx)
+}
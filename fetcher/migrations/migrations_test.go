@@ -0,0 +1,128 @@
+package migrations
+
+import (
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// scripts builds a file system with an sql directory holding one file per
+// name, whose content is the name itself.
+func scripts(names ...string) fstest.MapFS {
+	fsys := fstest.MapFS{"sql": &fstest.MapFile{Mode: fs.ModeDir | 0755}}
+	for _, name := range names {
+		fsys["sql/"+name] = &fstest.MapFile{Data: []byte(name)}
+	}
+	return fsys
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name:  "pairs and orders the scripts",
+			files: []string{"0002_price_history.down.sql", "0001_baseline.up.sql", "0002_price_history.up.sql", "0001_baseline.down.sql"},
+			want: []Migration{
+				{Version: 1, Name: "baseline", Up: "0001_baseline.up.sql", Down: "0001_baseline.down.sql"},
+				{Version: 2, Name: "price_history", Up: "0002_price_history.up.sql", Down: "0002_price_history.down.sql"},
+			},
+		},
+		{
+			name:  "version without leading zeros and name with underscores",
+			files: []string{"1_add_saving_plans_index.up.sql", "1_add_saving_plans_index.down.sql"},
+			want: []Migration{
+				{Version: 1, Name: "add_saving_plans_index", Up: "1_add_saving_plans_index.up.sql", Down: "1_add_saving_plans_index.down.sql"},
+			},
+		},
+		{
+			name:  "no migrations",
+			files: nil,
+			want:  []Migration{},
+		},
+		{
+			name:    "missing down script",
+			files:   []string{"0001_baseline.up.sql"},
+			wantErr: "needs both an up and a down script",
+		},
+		{
+			name:    "missing up script",
+			files:   []string{"0001_baseline.down.sql"},
+			wantErr: "needs both an up and a down script",
+		},
+		{
+			name:    "gap in the sequence",
+			files:   []string{"0001_a.up.sql", "0001_a.down.sql", "0003_c.up.sql", "0003_c.down.sql"},
+			wantErr: "migration 2 is missing",
+		},
+		{
+			name:    "sequence not starting at 1",
+			files:   []string{"0002_b.up.sql", "0002_b.down.sql"},
+			wantErr: "migration 1 is missing",
+		},
+		{
+			name:    "version used by two names",
+			files:   []string{"0001_a.up.sql", "0001_a.down.sql", "0001_b.up.sql", "0001_b.down.sql"},
+			wantErr: `named both "a" and "b"`,
+		},
+		{
+			name:    "version written twice",
+			files:   []string{"0001_a.up.sql", "0001_a.down.sql", "01_a.up.sql"},
+			wantErr: "more than one up script",
+		},
+		{
+			name:    "no version number",
+			files:   []string{"baseline.up.sql", "baseline.down.sql"},
+			wantErr: "does not start with a version number",
+		},
+		{
+			name:    "version 0",
+			files:   []string{"0000_baseline.up.sql", "0000_baseline.down.sql"},
+			wantErr: "does not start with a version number",
+		},
+		{
+			name:    "file that is not a script",
+			files:   []string{"0001_a.up.sql", "0001_a.down.sql", "README.md"},
+			wantErr: "neither .up.sql nor .down.sql",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := load(scripts(tt.files...))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 || migrations[0].Name != "baseline" {
+		t.Fatalf("embedded migrations do not start with the baseline: %+v", migrations)
+	}
+	latest, err := Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != len(migrations) {
+		t.Errorf("Latest() = %d, want %d", latest, len(migrations))
+	}
+}
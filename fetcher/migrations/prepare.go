@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// prepare holds the steps that run in Go right before the script of a
+// migration, for work SQL alone would do without a trace, such as removing
+// the rows a new unique index would reject.
+var prepare = map[int]func(tx *gorm.DB) error{
+	5: dedupeAzureSavingPlans,
+	6: dedupeDataQualityIssues,
+}
+
+// dedupeAzureSavingPlans keeps only the newest of the Azure savings plan
+// rates loaded more than once for the same region and rate code, before
+// migration 5 makes them unique, and reports how many were removed. AWS
+// rates are not touched.
+func dedupeAzureSavingPlans(tx *gorm.DB) error {
+	result := tx.Exec(`DELETE FROM saving_plans a
+		USING saving_plans b
+		WHERE a.region_id = b.region_id AND a.rate_code = b.rate_code AND a.id < b.id
+		AND a.rate_code LIKE '%.SavingsPlan.%'`)
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove duplicate Azure savings plan rates: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		fmt.Printf("Removed %d duplicate Azure savings plan rates\n", result.RowsAffected)
+	}
+	return nil
+}
+
+// dedupeDataQualityIssues keeps only the newest of the data quality issues
+// recorded more than once for the same value, before migration 6 makes them
+// unique, and reports how many were removed.
+func dedupeDataQualityIssues(tx *gorm.DB) error {
+	result := tx.Exec(`DELETE FROM data_quality_issues a
+		USING data_quality_issues b
+		WHERE a.provider = b.provider AND a.entity = b.entity AND a.key = b.key AND a.field = b.field AND a.id < b.id`)
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove duplicate data quality issues: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		fmt.Printf("Removed %d duplicate data quality issues\n", result.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS data_quality_issues;
+DROP TABLE IF EXISTS ingestion_run_steps;
+DROP TABLE IF EXISTS ingestion_runs;
+DROP TABLE IF EXISTS offer_versions;
+DROP TABLE IF EXISTS saving_plans;
+DROP TABLE IF EXISTS terms;
+DROP TABLE IF EXISTS prices;
+DROP TABLE IF EXISTS skus;
+DROP TABLE IF EXISTS services;
+DROP TABLE IF EXISTS regions;
+DROP TABLE IF EXISTS providers;
@@ -0,0 +1,217 @@
+-- Baseline: the tables as they were before versioned migrations. Each table
+-- is created with the columns AutoMigrate gave it originally, then the
+-- columns added since are added, so the same guarded statements build a new
+-- database and adopt one whose tables AutoMigrate created.
+
+CREATE TABLE IF NOT EXISTS providers (
+    provider_id   bigserial PRIMARY KEY,
+    provider_name varchar(50) NOT NULL CONSTRAINT uni_providers_provider_name UNIQUE,
+    created_date  timestamptz DEFAULT current_timestamp,
+    modified_date timestamptz DEFAULT current_timestamp,
+    disable_flag  boolean DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS regions (
+    region_id     bigserial PRIMARY KEY,
+    provider_id   bigint NOT NULL,
+    region_code   text,
+    region_name   text,
+    created_date  timestamptz DEFAULT current_timestamp,
+    modified_date timestamptz DEFAULT current_timestamp,
+    disable_flag  boolean DEFAULT false
+);
+-- Region codes used to be unique on their own; they are unique per provider.
+ALTER TABLE regions DROP CONSTRAINT IF EXISTS uni_regions_region_code;
+DROP INDEX IF EXISTS idx_regions_region_code;
+ALTER TABLE regions
+    ADD COLUMN IF NOT EXISTS display_name varchar(100),
+    ADD COLUMN IF NOT EXISTS country      varchar(2),
+    ADD COLUMN IF NOT EXISTS continent    varchar(20),
+    ADD COLUMN IF NOT EXISTS latitude     decimal,
+    ADD COLUMN IF NOT EXISTS longitude    decimal,
+    ADD COLUMN IF NOT EXISTS zone_type    varchar(20),
+    ADD COLUMN IF NOT EXISTS location_key varchar(50);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_regions_identity ON regions (provider_id, region_code);
+CREATE INDEX IF NOT EXISTS idx_regions_location_key ON regions (location_key);
+
+CREATE TABLE IF NOT EXISTS services (
+    service_id    bigserial PRIMARY KEY,
+    provider_id   bigint NOT NULL,
+    service_code  text CONSTRAINT uni_services_service_code UNIQUE,
+    service_name  text,
+    created_date  timestamptz DEFAULT current_timestamp,
+    modified_date timestamptz DEFAULT current_timestamp,
+    disable_flag  boolean DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS skus (
+    id                  bigserial PRIMARY KEY,
+    region_id           bigint NOT NULL,
+    provider_id         bigint NOT NULL,
+    region_code         text NOT NULL,
+    sku_code            text,
+    arm_sku_name        text,
+    instance_sku        text,
+    product_family      text,
+    vcpu                bigint,
+    cpu_architecture    text,
+    instance_type       text,
+    storage             text,
+    network             text,
+    operating_system    text,
+    type                text,
+    memory              text,
+    physical_processor  text,
+    max_throughput      text,
+    enhanced_networking text,
+    gpu                 text,
+    max_iops            text,
+    created_date        timestamptz DEFAULT current_timestamp,
+    modified_date       timestamptz DEFAULT current_timestamp,
+    disable_flag        boolean DEFAULT false
+);
+-- SKU codes used to be unique on their own; they are unique per provider and
+-- region. idx_skus_sku_code is recreated below as a plain index.
+ALTER TABLE skus DROP CONSTRAINT IF EXISTS uni_skus_sku_code;
+DROP INDEX IF EXISTS idx_skus_sku_code;
+ALTER TABLE skus
+    ADD COLUMN IF NOT EXISTS service_id            bigint,
+    ADD COLUMN IF NOT EXISTS ebs_only              boolean,
+    ADD COLUMN IF NOT EXISTS disk_count            bigint,
+    ADD COLUMN IF NOT EXISTS disk_size_gb          decimal,
+    ADD COLUMN IF NOT EXISTS total_local_gb        decimal,
+    ADD COLUMN IF NOT EXISTS storage_media_type    varchar(20),
+    ADD COLUMN IF NOT EXISTS network_baseline_mbps decimal,
+    ADD COLUMN IF NOT EXISTS network_burst_mbps    decimal,
+    ADD COLUMN IF NOT EXISTS memory_gib            decimal;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_skus_identity ON skus (provider_id, region_id, sku_code);
+CREATE INDEX IF NOT EXISTS idx_skus_sku_code ON skus (sku_code);
+CREATE INDEX IF NOT EXISTS idx_skus_service_id ON skus (service_id);
+
+CREATE TABLE IF NOT EXISTS prices (
+    price_id       bigserial PRIMARY KEY,
+    sku_id         bigint NOT NULL,
+    effective_date varchar(255),
+    unit           varchar(50),
+    description    varchar(255),
+    price_per_unit numeric(20,10),
+    created_date   timestamptz DEFAULT current_timestamp,
+    modified_date  timestamptz DEFAULT current_timestamp,
+    disable_flag   boolean DEFAULT false
+);
+-- Prices used to be stored as text; blank ones become NULL.
+ALTER TABLE prices
+    ALTER COLUMN price_per_unit TYPE numeric(20,10) USING NULLIF(trim(price_per_unit::text), '')::numeric(20,10);
+ALTER TABLE prices
+    ADD COLUMN IF NOT EXISTS term_type   varchar(50),
+    ADD COLUMN IF NOT EXISTS rate_code   varchar(255),
+    ADD COLUMN IF NOT EXISTS currency    varchar(3),
+    ADD COLUMN IF NOT EXISTS begin_range decimal DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS end_range   decimal,
+    ADD COLUMN IF NOT EXISTS applies_to  text;
+CREATE INDEX IF NOT EXISTS idx_prices_term_type ON prices (term_type);
+CREATE INDEX IF NOT EXISTS idx_prices_currency ON prices (currency);
+
+CREATE TABLE IF NOT EXISTS terms (
+    offer_term_id         bigserial PRIMARY KEY,
+    sku_id                bigint NOT NULL,
+    price_id              bigint NOT NULL,
+    lease_contract_length varchar(255),
+    purchase_option       varchar(255),
+    offering_class        varchar(255),
+    created_date          timestamptz DEFAULT current_timestamp,
+    modified_date         timestamptz DEFAULT current_timestamp,
+    disable_flag          boolean DEFAULT false
+);
+ALTER TABLE terms
+    ADD COLUMN IF NOT EXISTS lease_contract_years decimal;
+
+CREATE TABLE IF NOT EXISTS saving_plans (
+    id                       bigserial PRIMARY KEY,
+    discounted_sku           text,
+    sku                      text,
+    lease_contract_length    bigint,
+    discounted_rate          text,
+    region_id                bigint NOT NULL,
+    region_code              text NOT NULL,
+    provider_id              bigint NOT NULL,
+    discounted_instance_type text NOT NULL,
+    unit                     text NOT NULL,
+    created_date             timestamptz DEFAULT current_timestamp,
+    modified_date            timestamptz DEFAULT current_timestamp,
+    disable_flag             boolean DEFAULT false
+);
+ALTER TABLE saving_plans
+    ADD COLUMN IF NOT EXISTS discounted_sku_id       bigint,
+    ADD COLUMN IF NOT EXISTS plan_type               text,
+    ADD COLUMN IF NOT EXISTS purchase_option         text,
+    ADD COLUMN IF NOT EXISTS purchase_term           text,
+    ADD COLUMN IF NOT EXISTS instance_family         text,
+    ADD COLUMN IF NOT EXISTS usage_type              text,
+    ADD COLUMN IF NOT EXISTS description             text,
+    ADD COLUMN IF NOT EXISTS effective_date          text,
+    ADD COLUMN IF NOT EXISTS lease_contract_unit     text,
+    ADD COLUMN IF NOT EXISTS currency                varchar(3),
+    ADD COLUMN IF NOT EXISTS rate_code               text,
+    ADD COLUMN IF NOT EXISTS discounted_usage_type   text,
+    ADD COLUMN IF NOT EXISTS discounted_operation    text,
+    ADD COLUMN IF NOT EXISTS discounted_service_code text,
+    ADD COLUMN IF NOT EXISTS discounted_region_code  text;
+CREATE INDEX IF NOT EXISTS idx_saving_plans_discounted_sku_id ON saving_plans (discounted_sku_id);
+
+CREATE TABLE IF NOT EXISTS offer_versions (
+    id               bigserial PRIMARY KEY,
+    service_code     text NOT NULL,
+    region_code      text NOT NULL,
+    version          text NOT NULL,
+    publication_date text,
+    created_date     timestamptz DEFAULT current_timestamp,
+    modified_date    timestamptz DEFAULT current_timestamp
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_offer_version_region ON offer_versions (service_code, region_code);
+
+CREATE TABLE IF NOT EXISTS ingestion_runs (
+    id            bigserial PRIMARY KEY,
+    run_id        varchar(32),
+    provider      varchar(50),
+    status        varchar(20),
+    started_at    timestamptz,
+    finished_at   timestamptz,
+    rows_inserted bigint,
+    rows_updated  bigint,
+    rows_disabled bigint,
+    error         text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingestion_runs_run_id ON ingestion_runs (run_id);
+CREATE INDEX IF NOT EXISTS idx_ingestion_runs_provider ON ingestion_runs (provider);
+CREATE INDEX IF NOT EXISTS idx_ingestion_runs_started_at ON ingestion_runs (started_at);
+
+CREATE TABLE IF NOT EXISTS ingestion_run_steps (
+    id            bigserial PRIMARY KEY,
+    run_id        varchar(32),
+    provider      varchar(50),
+    step          varchar(50),
+    service       varchar(100),
+    region        varchar(100),
+    status        varchar(20),
+    started_at    timestamptz,
+    finished_at   timestamptz,
+    rows_inserted bigint,
+    rows_updated  bigint,
+    rows_disabled bigint,
+    error         text
+);
+CREATE INDEX IF NOT EXISTS idx_ingestion_run_steps_run_id ON ingestion_run_steps (run_id);
+
+CREATE TABLE IF NOT EXISTS data_quality_issues (
+    id           bigserial PRIMARY KEY,
+    provider     varchar(50),
+    entity       varchar(50),
+    key          text,
+    field        varchar(100),
+    raw_value    text,
+    reason       text,
+    created_date timestamptz DEFAULT current_timestamp
+);
+CREATE INDEX IF NOT EXISTS idx_data_quality_issues_provider ON data_quality_issues (provider);
+CREATE INDEX IF NOT EXISTS idx_data_quality_issues_key ON data_quality_issues (key);
@@ -0,0 +1,37 @@
+-- Effective-dated prices (SCD type 2). A row is valid from valid_from up to,
+-- but not including, valid_to; the current row of a price has valid_to NULL.
+-- Rows are written when temp_db is promoted and only when a price changes.
+CREATE TABLE price_history (
+    id                    bigserial PRIMARY KEY,
+    provider_name         varchar(50) NOT NULL,
+    region_code           text NOT NULL,
+    sku_code              text NOT NULL,
+    instance_type         text NOT NULL DEFAULT '',
+    operating_system      text NOT NULL DEFAULT '',
+    product_family        text NOT NULL DEFAULT '',
+    term_type             varchar(50) NOT NULL DEFAULT '',
+    rate_code             varchar(255) NOT NULL DEFAULT '',
+    lease_contract_length varchar(255) NOT NULL DEFAULT '',
+    purchase_option       varchar(255) NOT NULL DEFAULT '',
+    offering_class        varchar(255) NOT NULL DEFAULT '',
+    unit                  varchar(50) NOT NULL DEFAULT '',
+    currency              varchar(3) NOT NULL DEFAULT '',
+    begin_range           decimal NOT NULL DEFAULT 0,
+    end_range             decimal,
+    price_per_unit        numeric(20,10),
+    description           varchar(255),
+    effective_date        varchar(255),
+    valid_from            timestamptz NOT NULL,
+    valid_to              timestamptz,
+    CHECK (valid_to IS NULL OR valid_to > valid_from)
+);
+
+-- At most one current row per price
+CREATE UNIQUE INDEX idx_price_history_current ON price_history (
+    provider_name, region_code, sku_code, term_type, rate_code, lease_contract_length,
+    purchase_option, offering_class, unit, currency, begin_range
+) WHERE valid_to IS NULL;
+
+-- Point-in-time lookups by SKU or by instance type
+CREATE INDEX idx_price_history_sku ON price_history (provider_name, region_code, sku_code, valid_from);
+CREATE INDEX idx_price_history_instance_type ON price_history (provider_name, region_code, instance_type, valid_from);
@@ -0,0 +1,6 @@
+ALTER TABLE skus
+    DROP COLUMN IF EXISTS acus,
+    DROP COLUMN IF EXISTS max_data_disk_count,
+    DROP COLUMN IF EXISTS premium_io,
+    DROP COLUMN IF EXISTS hyper_v_generations,
+    DROP COLUMN IF EXISTS capabilities;
@@ -0,0 +1,8 @@
+-- Capabilities of Azure resource SKUs without an AWS counterpart, and every
+-- capability as published for those not mapped to a column.
+ALTER TABLE skus
+    ADD COLUMN IF NOT EXISTS acus bigint,
+    ADD COLUMN IF NOT EXISTS max_data_disk_count bigint,
+    ADD COLUMN IF NOT EXISTS premium_io boolean,
+    ADD COLUMN IF NOT EXISTS hyper_v_generations varchar(20),
+    ADD COLUMN IF NOT EXISTS capabilities jsonb;
@@ -0,0 +1,9 @@
+ALTER TABLE prices
+    DROP COLUMN IF EXISTS price_type,
+    DROP COLUMN IF EXISTS meter_id,
+    DROP COLUMN IF EXISTS meter_name,
+    DROP COLUMN IF EXISTS product_name,
+    DROP COLUMN IF EXISTS sku_name,
+    DROP COLUMN IF EXISTS is_spot,
+    DROP COLUMN IF EXISTS is_low_priority,
+    DROP COLUMN IF EXISTS is_primary_meter_region;
@@ -0,0 +1,11 @@
+-- Source price type, meter and product metadata, and the Spot and Low
+-- Priority flags of Azure retail prices.
+ALTER TABLE prices
+    ADD COLUMN IF NOT EXISTS price_type varchar(50),
+    ADD COLUMN IF NOT EXISTS meter_id varchar(64),
+    ADD COLUMN IF NOT EXISTS meter_name text,
+    ADD COLUMN IF NOT EXISTS product_name text,
+    ADD COLUMN IF NOT EXISTS sku_name text,
+    ADD COLUMN IF NOT EXISTS is_spot boolean DEFAULT false,
+    ADD COLUMN IF NOT EXISTS is_low_priority boolean DEFAULT false,
+    ADD COLUMN IF NOT EXISTS is_primary_meter_region boolean;
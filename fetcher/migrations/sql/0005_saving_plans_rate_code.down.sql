@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_saving_plans_region_rate_code;
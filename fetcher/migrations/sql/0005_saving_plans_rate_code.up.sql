@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_saving_plans_region_rate_code;
+-- Azure savings plan rates are unique per region and rate code, and upserted
+-- on it. Their rate codes are "<meterId>.SavingsPlan.<term>"; AWS rates and
+-- rows without a rate code are left out of the index. Duplicates left by
+-- earlier loads are removed before this script runs.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_saving_plans_region_rate_code ON saving_plans (region_id, rate_code)
+    WHERE rate_code LIKE '%.SavingsPlan.%';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_data_quality_issues_identity;
+ALTER TABLE data_quality_issues
+    DROP COLUMN IF EXISTS modified_date;
@@ -0,0 +1,6 @@
+-- A data quality issue is identified by the value it is about, so a value
+-- that fails on every load is stored once and refreshed. Duplicates recorded
+-- before are removed by the migrations package ahead of this script.
+ALTER TABLE data_quality_issues
+    ADD COLUMN IF NOT EXISTS modified_date timestamptz DEFAULT current_timestamp;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_data_quality_issues_identity ON data_quality_issues (provider, entity, key, field);
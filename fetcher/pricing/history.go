@@ -0,0 +1,146 @@
+package pricing
+
+import (
+	"fmt"
+	"time"
+
+	"cco-package/fetcher/schema"
+	"gorm.io/gorm"
+)
+
+// historyKey are the price_history columns that identify a price across
+// promotions. A price whose key has no current row is new; a current row
+// whose key is gone or whose price changed is closed.
+const historyKey = `provider_name, region_code, sku_code, term_type, rate_code, lease_contract_length,
+	purchase_option, offering_class, unit, currency, begin_range`
+
+const historyMatch = `h.provider_name = c.provider_name AND h.region_code = c.region_code AND h.sku_code = c.sku_code
+	AND h.term_type = c.term_type AND h.rate_code = c.rate_code AND h.lease_contract_length = c.lease_contract_length
+	AND h.purchase_option = c.purchase_option AND h.offering_class = c.offering_class
+	AND h.unit = c.unit AND h.currency = c.currency AND h.begin_range = c.begin_range`
+
+// promotedPrices flattens the prices of the database, with their SKU, region,
+// provider and term, into the columns of price_history.
+const promotedPrices = `CREATE TEMP TABLE promoted_prices ON COMMIT DROP AS
+SELECT DISTINCT ON (` + historyKey + `) *
+FROM (
+	SELECT pr.provider_name, r.region_code, s.sku_code,
+		COALESCE(s.instance_type, '') AS instance_type,
+		COALESCE(s.operating_system, '') AS operating_system,
+		COALESCE(s.product_family, '') AS product_family,
+		COALESCE(p.term_type, '') AS term_type,
+		COALESCE(p.rate_code, '') AS rate_code,
+		COALESCE(t.lease_contract_length, '') AS lease_contract_length,
+		COALESCE(t.purchase_option, '') AS purchase_option,
+		COALESCE(t.offering_class, '') AS offering_class,
+		COALESCE(p.unit, '') AS unit,
+		COALESCE(p.currency, '') AS currency,
+		COALESCE(p.begin_range, 0) AS begin_range,
+		p.end_range, p.price_per_unit, p.description, p.effective_date, p.price_id
+	FROM prices p
+	JOIN skus s ON s.id = p.sku_id
+	JOIN regions r ON r.region_id = s.region_id
+	JOIN providers pr ON pr.provider_id = s.provider_id
+	LEFT JOIN terms t ON t.price_id = p.price_id
+	WHERE NOT COALESCE(p.disable_flag, false)
+) flat
+ORDER BY ` + historyKey + `, price_id DESC`
+
+// RecordHistory brings price_history in line with the prices of db, as of
+// at. The current row of a price that changed or disappeared gets valid_to
+// at, and a new row valid from at is written for every new or changed price;
+// unchanged prices keep their row. Only providers present in prices are
+// closed, so a provider whose fetch produced nothing keeps its history open.
+// It returns the number of rows opened and closed.
+func RecordHistory(db *gorm.DB, at time.Time) (opened, closed int64, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(promotedPrices).Error; err != nil {
+			return fmt.Errorf("failed to collect prices: %v", err)
+		}
+
+		result := tx.Exec(`UPDATE price_history h SET valid_to = ?
+			WHERE h.valid_to IS NULL
+			AND h.provider_name IN (SELECT DISTINCT provider_name FROM promoted_prices)
+			AND NOT EXISTS (
+				SELECT 1 FROM promoted_prices c
+				WHERE `+historyMatch+`
+				AND c.price_per_unit IS NOT DISTINCT FROM h.price_per_unit
+				AND c.end_range IS NOT DISTINCT FROM h.end_range
+			)`, at)
+		if result.Error != nil {
+			return fmt.Errorf("failed to close changed prices: %v", result.Error)
+		}
+		closed = result.RowsAffected
+
+		result = tx.Exec(`INSERT INTO price_history (`+historyKey+`,
+				instance_type, operating_system, product_family, end_range, price_per_unit,
+				description, effective_date, valid_from)
+			SELECT `+historyKey+`,
+				instance_type, operating_system, product_family, end_range, price_per_unit,
+				description, effective_date, ?
+			FROM promoted_prices c
+			WHERE NOT EXISTS (SELECT 1 FROM price_history h WHERE h.valid_to IS NULL AND `+historyMatch+`)`, at)
+		if result.Error != nil {
+			return fmt.Errorf("failed to insert new prices: %v", result.Error)
+		}
+		opened = result.RowsAffected
+		return nil
+	})
+	return opened, closed, err
+}
+
+// HistoryFilter selects prices in price_history. Empty fields match any value.
+type HistoryFilter struct {
+	Provider        string // "AWS", "Azure" or "GCP"
+	RegionCode      string // e.g. "eu-west-1"
+	SKUCode         string
+	InstanceType    string // e.g. "m5.large"
+	OperatingSystem string
+	TermType        string // e.g. "OnDemand"
+	Currency        string
+}
+
+func (f HistoryFilter) scope(db *gorm.DB) *gorm.DB {
+	conditions := []struct{ column, value string }{
+		{"provider_name", f.Provider},
+		{"region_code", f.RegionCode},
+		{"sku_code", f.SKUCode},
+		{"instance_type", f.InstanceType},
+		{"operating_system", f.OperatingSystem},
+		{"term_type", f.TermType},
+		{"currency", f.Currency},
+	}
+	for _, c := range conditions {
+		if c.value != "" {
+			db = db.Where(c.column+" = ?", c.value)
+		}
+	}
+	return db
+}
+
+// PricesAt returns the prices matching filter that were valid at the given
+// time, e.g. what an m5.large cost in eu-west-1 last March.
+func PricesAt(db *gorm.DB, filter HistoryFilter, at time.Time) ([]schema.PriceHistory, error) {
+	var prices []schema.PriceHistory
+	err := db.Scopes(filter.scope).
+		Where("valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)", at, at).
+		Order("sku_code, term_type, begin_range").
+		Find(&prices).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices at %s: %v", at.Format(time.RFC3339), err)
+	}
+	return prices, nil
+}
+
+// PriceHistory returns every version of the prices matching filter, oldest
+// first.
+func PriceHistory(db *gorm.DB, filter HistoryFilter) ([]schema.PriceHistory, error) {
+	var prices []schema.PriceHistory
+	err := db.Scopes(filter.scope).
+		Order("sku_code, term_type, begin_range, valid_from").
+		Find(&prices).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price history: %v", err)
+	}
+	return prices, nil
+}
@@ -0,0 +1,67 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+
+	"cco-package/fetcher/schema"
+	"gorm.io/gorm"
+)
+
+// TierForUsage returns the OnDemand price tier of a SKU in the given currency
+// that applies to the usage quantity, i.e. the tier with
+// begin_range <= quantity < end_range. For tiered products such as data
+// transfer or EBS the quantity is expressed in the price's unit (GB, GB-Mo, ...).
+func TierForUsage(db *gorm.DB, skuID uint, currency string, quantity float64) (*schema.Price, error) {
+	var price schema.Price
+	err := db.Where("sku_id = ? AND term_type = ? AND currency = ?", skuID, "OnDemand", currency).
+		Where("begin_range <= ? AND (end_range IS NULL OR end_range > ?)", quantity, quantity).
+		Order("begin_range DESC").
+		First(&price).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("no %s price tier for SKU %d at quantity %v", currency, skuID, quantity)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price tier: %v", err)
+	}
+	return &price, nil
+}
+
+// Tiers returns every OnDemand price tier of a SKU in the given currency,
+// ordered by begin_range.
+func Tiers(db *gorm.DB, skuID uint, currency string) ([]schema.Price, error) {
+	var prices []schema.Price
+	err := db.Where("sku_id = ? AND term_type = ? AND currency = ?", skuID, "OnDemand", currency).
+		Order("begin_range").
+		Find(&prices).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch price tiers: %v", err)
+	}
+	return prices, nil
+}
+
+// PricesInCurrency returns every price of a SKU, OnDemand and Reserved, in
+// the given currency.
+func PricesInCurrency(db *gorm.DB, skuID uint, currency string) ([]schema.Price, error) {
+	var prices []schema.Price
+	err := db.Where("sku_id = ? AND currency = ?", skuID, currency).
+		Order("term_type, begin_range").
+		Find(&prices).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s prices: %v", currency, err)
+	}
+	return prices, nil
+}
+
+// Currencies returns the currencies a SKU is priced in.
+func Currencies(db *gorm.DB, skuID uint) ([]string, error) {
+	var currencies []string
+	err := db.Model(&schema.Price{}).
+		Where("sku_id = ?", skuID).
+		Distinct("currency").
+		Pluck("currency", &currencies).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch currencies: %v", err)
+	}
+	return currencies, nil
+}
@@ -0,0 +1,72 @@
+// Package quality records source values that could not be converted into
+// their typed columns. The column is stored as NULL and the raw value is kept
+// in data_quality_issues, so bad input is visible instead of silently lost.
+package quality
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DataQualityIssue is one attribute of one row that could not be parsed. An
+// issue is identified by its provider, entity, key and field, so a value that
+// fails on every load is stored once.
+type DataQualityIssue struct {
+	ID           uint      `gorm:"primaryKey"`
+	Provider     string    `gorm:"size:50;index;uniqueIndex:idx_data_quality_issues_identity,priority:1"`
+	Entity       string    `gorm:"size:50;uniqueIndex:idx_data_quality_issues_identity,priority:2"`  // Table the value belongs to, e.g. "skus" or "terms"
+	Key          string    `gorm:"index;uniqueIndex:idx_data_quality_issues_identity,priority:3"`    // Source identifier of the row, usually the SKU code
+	Field        string    `gorm:"size:100;uniqueIndex:idx_data_quality_issues_identity,priority:4"` // Typed column that was left NULL
+	RawValue     string    `gorm:"type:text"`
+	Reason       string    `gorm:"type:text"`
+	CreatedDate  time.Time `gorm:"default:current_timestamp"` // First load the value failed in
+	ModifiedDate time.Time `gorm:"default:current_timestamp"` // Latest load the value failed in
+}
+
+// Issue builds the issue for a value of field that failed to parse with err.
+func Issue(provider, entity, key, field, raw string, err error) DataQualityIssue {
+	return DataQualityIssue{
+		Provider: provider,
+		Entity:   entity,
+		Key:      key,
+		Field:    field,
+		RawValue: raw,
+		Reason:   err.Error(),
+	}
+}
+
+// Record upserts the given issues on their identity. An issue that was
+// already recorded gets the raw value and reason of this load.
+func Record(db *gorm.DB, issues ...DataQualityIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	// One statement cannot update the same row twice, so keep the last
+	// issue of every identity
+	now := time.Now()
+	index := make(map[DataQualityIssue]int, len(issues))
+	unique := make([]DataQualityIssue, 0, len(issues))
+	for _, issue := range issues {
+		issue.ModifiedDate = now
+		identity := DataQualityIssue{Provider: issue.Provider, Entity: issue.Entity, Key: issue.Key, Field: issue.Field}
+		if i, ok := index[identity]; ok {
+			unique[i] = issue
+			continue
+		}
+		index[identity] = len(unique)
+		unique = append(unique, issue)
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "entity"}, {Name: "key"}, {Name: "field"}},
+		DoUpdates: clause.AssignmentColumns([]string{"raw_value", "reason", "modified_date"}),
+	}).CreateInBatches(unique, 1000).Error
+	if err != nil {
+		return fmt.Errorf("failed to record %d data quality issues: %v", len(unique), err)
+	}
+	return nil
+}
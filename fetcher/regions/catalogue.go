@@ -0,0 +1,315 @@
+package regions
+
+// Continents.
+const (
+	NorthAmerica = "North America"
+	SouthAmerica = "South America"
+	Europe       = "Europe"
+	Africa       = "Africa"
+	Asia         = "Asia"
+	Oceania      = "Oceania"
+)
+
+// metro is a metro area that hosts regions of one or more providers.
+type metro struct {
+	city      string
+	country   string
+	continent string
+	lat, lon  float64
+}
+
+// metros is keyed by location key, "<country>-<metro>". Regions of different
+// providers that are close enough to be interchangeable share a key, so the
+// coordinates are those of the metro, not of the data centres.
+var metros = map[string]metro{
+	// North America
+	"us-virginia":       {"Northern Virginia", "US", NorthAmerica, 38.95, -77.45},
+	"us-ohio":           {"Ohio", "US", NorthAmerica, 39.96, -83.00},
+	"us-california":     {"Northern California", "US", NorthAmerica, 37.35, -121.96},
+	"us-los-angeles":    {"Los Angeles", "US", NorthAmerica, 34.05, -118.24},
+	"us-oregon":         {"Oregon", "US", NorthAmerica, 45.60, -121.20},
+	"us-portland":       {"Portland", "US", NorthAmerica, 45.52, -122.68},
+	"us-seattle":        {"Seattle", "US", NorthAmerica, 47.61, -122.33},
+	"us-washington":     {"Washington", "US", NorthAmerica, 47.23, -119.85},
+	"us-iowa":           {"Iowa", "US", NorthAmerica, 41.26, -95.86},
+	"us-texas":          {"Texas", "US", NorthAmerica, 29.42, -98.49},
+	"us-dallas":         {"Dallas", "US", NorthAmerica, 32.78, -96.80},
+	"us-houston":        {"Houston", "US", NorthAmerica, 29.76, -95.37},
+	"us-chicago":        {"Chicago", "US", NorthAmerica, 41.88, -87.63},
+	"us-phoenix":        {"Phoenix", "US", NorthAmerica, 33.45, -112.07},
+	"us-salt-lake-city": {"Salt Lake City", "US", NorthAmerica, 40.76, -111.89},
+	"us-las-vegas":      {"Las Vegas", "US", NorthAmerica, 36.17, -115.14},
+	"us-south-carolina": {"South Carolina", "US", NorthAmerica, 33.20, -80.01},
+	"us-wyoming":        {"Wyoming", "US", NorthAmerica, 41.14, -104.82},
+	"us-boston":         {"Boston", "US", NorthAmerica, 42.36, -71.06},
+	"us-new-york":       {"New York", "US", NorthAmerica, 40.71, -74.01},
+	"us-philadelphia":   {"Philadelphia", "US", NorthAmerica, 39.95, -75.17},
+	"us-miami":          {"Miami", "US", NorthAmerica, 25.76, -80.19},
+	"us-atlanta":        {"Atlanta", "US", NorthAmerica, 33.75, -84.39},
+	"us-charlotte":      {"Charlotte", "US", NorthAmerica, 35.23, -80.84},
+	"us-denver":         {"Denver", "US", NorthAmerica, 39.74, -104.99},
+	"us-detroit":        {"Detroit", "US", NorthAmerica, 42.33, -83.05},
+	"us-minneapolis":    {"Minneapolis", "US", NorthAmerica, 44.98, -93.27},
+	"us-kansas-city":    {"Kansas City", "US", NorthAmerica, 39.10, -94.58},
+	"us-honolulu":       {"Honolulu", "US", NorthAmerica, 21.31, -157.86},
+	"us-anchorage":      {"Anchorage", "US", NorthAmerica, 61.22, -149.90},
+	"ca-montreal":       {"Montreal", "CA", NorthAmerica, 45.50, -73.57},
+	"ca-toronto":        {"Toronto", "CA", NorthAmerica, 43.65, -79.38},
+	"ca-quebec":         {"Quebec City", "CA", NorthAmerica, 46.81, -71.21},
+	"ca-calgary":        {"Calgary", "CA", NorthAmerica, 51.05, -114.07},
+	"mx-queretaro":      {"Queretaro", "MX", NorthAmerica, 20.59, -100.39},
+
+	// South America
+	"br-sao-paulo":      {"Sao Paulo", "BR", SouthAmerica, -23.55, -46.63},
+	"br-rio-de-janeiro": {"Rio de Janeiro", "BR", SouthAmerica, -22.91, -43.17},
+	"cl-santiago":       {"Santiago", "CL", SouthAmerica, -33.45, -70.67},
+	"ar-buenos-aires":   {"Buenos Aires", "AR", SouthAmerica, -34.60, -58.38},
+	"pe-lima":           {"Lima", "PE", SouthAmerica, -12.05, -77.04},
+	"co-bogota":         {"Bogota", "CO", SouthAmerica, 4.71, -74.07},
+
+	// Europe
+	"ie-dublin":     {"Dublin", "IE", Europe, 53.35, -6.26},
+	"gb-london":     {"London", "GB", Europe, 51.51, -0.13},
+	"gb-cardiff":    {"Cardiff", "GB", Europe, 51.48, -3.18},
+	"de-frankfurt":  {"Frankfurt", "DE", Europe, 50.11, 8.68},
+	"de-berlin":     {"Berlin", "DE", Europe, 52.52, 13.40},
+	"de-hamburg":    {"Hamburg", "DE", Europe, 53.55, 9.99},
+	"fr-paris":      {"Paris", "FR", Europe, 48.86, 2.35},
+	"fr-marseille":  {"Marseille", "FR", Europe, 43.30, 5.37},
+	"nl-amsterdam":  {"Amsterdam", "NL", Europe, 52.37, 4.90},
+	"be-brussels":   {"Brussels", "BE", Europe, 50.85, 4.35},
+	"ch-zurich":     {"Zurich", "CH", Europe, 47.38, 8.54},
+	"ch-geneva":     {"Geneva", "CH", Europe, 46.20, 6.14},
+	"it-milan":      {"Milan", "IT", Europe, 45.46, 9.19},
+	"it-turin":      {"Turin", "IT", Europe, 45.07, 7.69},
+	"es-madrid":     {"Madrid", "ES", Europe, 40.42, -3.70},
+	"es-zaragoza":   {"Zaragoza", "ES", Europe, 41.65, -0.88},
+	"pt-lisbon":     {"Lisbon", "PT", Europe, 38.72, -9.14},
+	"se-stockholm":  {"Stockholm", "SE", Europe, 59.33, 18.07},
+	"se-gavle":      {"Gavle", "SE", Europe, 60.67, 17.14},
+	"no-oslo":       {"Oslo", "NO", Europe, 59.91, 10.75},
+	"no-stavanger":  {"Stavanger", "NO", Europe, 58.97, 5.73},
+	"fi-helsinki":   {"Helsinki", "FI", Europe, 60.17, 24.94},
+	"fi-hamina":     {"Hamina", "FI", Europe, 60.57, 27.20},
+	"dk-copenhagen": {"Copenhagen", "DK", Europe, 55.68, 12.57},
+	"pl-warsaw":     {"Warsaw", "PL", Europe, 52.23, 21.01},
+	"at-vienna":     {"Vienna", "AT", Europe, 48.21, 16.37},
+	"gr-athens":     {"Athens", "GR", Europe, 37.98, 23.73},
+
+	// Africa
+	"za-cape-town":    {"Cape Town", "ZA", Africa, -33.92, 18.42},
+	"za-johannesburg": {"Johannesburg", "ZA", Africa, -26.20, 28.05},
+	"ng-lagos":        {"Lagos", "NG", Africa, 6.52, 3.38},
+
+	// Asia, including the Middle East
+	"bh-manama":       {"Manama", "BH", Asia, 26.23, 50.59},
+	"ae-dubai":        {"Dubai", "AE", Asia, 25.20, 55.27},
+	"ae-abu-dhabi":    {"Abu Dhabi", "AE", Asia, 24.45, 54.38},
+	"qa-doha":         {"Doha", "QA", Asia, 25.29, 51.53},
+	"sa-dammam":       {"Dammam", "SA", Asia, 26.43, 50.10},
+	"il-tel-aviv":     {"Tel Aviv", "IL", Asia, 32.09, 34.78},
+	"in-mumbai":       {"Mumbai", "IN", Asia, 19.08, 72.88},
+	"in-pune":         {"Pune", "IN", Asia, 18.52, 73.86},
+	"in-chennai":      {"Chennai", "IN", Asia, 13.08, 80.27},
+	"in-hyderabad":    {"Hyderabad", "IN", Asia, 17.39, 78.49},
+	"in-delhi":        {"Delhi", "IN", Asia, 28.61, 77.21},
+	"in-kolkata":      {"Kolkata", "IN", Asia, 22.57, 88.36},
+	"sg-singapore":    {"Singapore", "SG", Asia, 1.35, 103.82},
+	"id-jakarta":      {"Jakarta", "ID", Asia, -6.21, 106.85},
+	"my-kuala-lumpur": {"Kuala Lumpur", "MY", Asia, 3.14, 101.69},
+	"th-bangkok":      {"Bangkok", "TH", Asia, 13.76, 100.50},
+	"ph-manila":       {"Manila", "PH", Asia, 14.60, 120.98},
+	"hk-hong-kong":    {"Hong Kong", "HK", Asia, 22.32, 114.17},
+	"tw-taipei":       {"Taipei", "TW", Asia, 25.03, 121.57},
+	"tw-changhua":     {"Changhua", "TW", Asia, 24.05, 120.52},
+	"jp-tokyo":        {"Tokyo", "JP", Asia, 35.68, 139.69},
+	"jp-osaka":        {"Osaka", "JP", Asia, 34.69, 135.50},
+	"kr-seoul":        {"Seoul", "KR", Asia, 37.57, 126.98},
+	"kr-busan":        {"Busan", "KR", Asia, 35.18, 129.08},
+	"cn-beijing":      {"Beijing", "CN", Asia, 39.90, 116.41},
+	"cn-ningxia":      {"Ningxia", "CN", Asia, 38.47, 106.27},
+	"cn-shanghai":     {"Shanghai", "CN", Asia, 31.23, 121.47},
+
+	// Oceania
+	"au-sydney":    {"Sydney", "AU", Oceania, -33.87, 151.21},
+	"au-melbourne": {"Melbourne", "AU", Oceania, -37.81, 144.96},
+	"au-canberra":  {"Canberra", "AU", Oceania, -35.28, 149.13},
+	"au-perth":     {"Perth", "AU", Oceania, -31.95, 115.86},
+	"nz-auckland":  {"Auckland", "NZ", Oceania, -36.85, 174.76},
+}
+
+// awsRegion is the AWS name and metro of a region code.
+type awsRegion struct {
+	name  string
+	metro string
+}
+
+// awsRegions are the AWS regions, named like their "location" attribute.
+var awsRegions = map[string]awsRegion{
+	"us-east-1":      {"US East (N. Virginia)", "us-virginia"},
+	"us-east-2":      {"US East (Ohio)", "us-ohio"},
+	"us-west-1":      {"US West (N. California)", "us-california"},
+	"us-west-2":      {"US West (Oregon)", "us-oregon"},
+	"us-gov-east-1":  {"AWS GovCloud (US-East)", "us-ohio"},
+	"us-gov-west-1":  {"AWS GovCloud (US-West)", "us-oregon"},
+	"ca-central-1":   {"Canada (Central)", "ca-montreal"},
+	"ca-west-1":      {"Canada West (Calgary)", "ca-calgary"},
+	"mx-central-1":   {"Mexico (Central)", "mx-queretaro"},
+	"sa-east-1":      {"South America (Sao Paulo)", "br-sao-paulo"},
+	"eu-west-1":      {"EU (Ireland)", "ie-dublin"},
+	"eu-west-2":      {"EU (London)", "gb-london"},
+	"eu-west-3":      {"EU (Paris)", "fr-paris"},
+	"eu-central-1":   {"EU (Frankfurt)", "de-frankfurt"},
+	"eu-central-2":   {"EU (Zurich)", "ch-zurich"},
+	"eu-south-1":     {"EU (Milan)", "it-milan"},
+	"eu-south-2":     {"EU (Spain)", "es-zaragoza"},
+	"eu-north-1":     {"EU (Stockholm)", "se-stockholm"},
+	"af-south-1":     {"Africa (Cape Town)", "za-cape-town"},
+	"il-central-1":   {"Israel (Tel Aviv)", "il-tel-aviv"},
+	"me-south-1":     {"Middle East (Bahrain)", "bh-manama"},
+	"me-central-1":   {"Middle East (UAE)", "ae-dubai"},
+	"ap-south-1":     {"Asia Pacific (Mumbai)", "in-mumbai"},
+	"ap-south-2":     {"Asia Pacific (Hyderabad)", "in-hyderabad"},
+	"ap-east-1":      {"Asia Pacific (Hong Kong)", "hk-hong-kong"},
+	"ap-southeast-1": {"Asia Pacific (Singapore)", "sg-singapore"},
+	"ap-southeast-2": {"Asia Pacific (Sydney)", "au-sydney"},
+	"ap-southeast-3": {"Asia Pacific (Jakarta)", "id-jakarta"},
+	"ap-southeast-4": {"Asia Pacific (Melbourne)", "au-melbourne"},
+	"ap-southeast-5": {"Asia Pacific (Malaysia)", "my-kuala-lumpur"},
+	"ap-southeast-7": {"Asia Pacific (Thailand)", "th-bangkok"},
+	"ap-northeast-1": {"Asia Pacific (Tokyo)", "jp-tokyo"},
+	"ap-northeast-2": {"Asia Pacific (Seoul)", "kr-seoul"},
+	"ap-northeast-3": {"Asia Pacific (Osaka)", "jp-osaka"},
+	"cn-north-1":     {"China (Beijing)", "cn-beijing"},
+	"cn-northwest-1": {"China (Ningxia)", "cn-ningxia"},
+}
+
+// awsZoneMetros maps the metro part of Local Zone and Wavelength Zone codes,
+// usually an airport code, to a metro.
+var awsZoneMetros = map[string]string{
+	"anc": "us-anchorage", "atl": "us-atlanta", "bos": "us-boston", "chi": "us-chicago",
+	"clt": "us-charlotte", "den": "us-denver", "dfw": "us-dallas", "dtw": "us-detroit",
+	"hnl": "us-honolulu", "hou": "us-houston", "iah": "us-houston", "las": "us-las-vegas",
+	"lax": "us-los-angeles", "mci": "us-kansas-city", "mia": "us-miami", "msp": "us-minneapolis",
+	"nyc": "us-new-york", "pdx": "us-portland", "phl": "us-philadelphia", "phx": "us-phoenix",
+	"sea": "us-seattle", "sfo": "us-california", "was": "us-virginia",
+	"qro": "mx-queretaro", "bue": "ar-buenos-aires", "lim": "pe-lima", "scl": "cl-santiago",
+	"bog": "co-bogota", "ham": "de-hamburg", "ber": "de-berlin", "lon": "gb-london",
+	"waw": "pl-warsaw", "cph": "dk-copenhagen", "hel": "fi-helsinki", "los": "ng-lagos",
+	"del": "in-delhi", "ccu": "in-kolkata", "tpe": "tw-taipei", "bkk": "th-bangkok",
+	"mnl": "ph-manila", "per": "au-perth", "akl": "nz-auckland", "tok": "jp-tokyo",
+	"osa": "jp-osaka", "sel": "kr-seoul",
+}
+
+// azureRegions maps Azure ARM region names to a metro.
+var azureRegions = map[string]string{
+	"eastus":             "us-virginia",
+	"eastus2":            "us-virginia",
+	"centralus":          "us-iowa",
+	"northcentralus":     "us-chicago",
+	"southcentralus":     "us-texas",
+	"westcentralus":      "us-wyoming",
+	"westus":             "us-california",
+	"westus2":            "us-washington",
+	"westus3":            "us-phoenix",
+	"usgovvirginia":      "us-virginia",
+	"usgovarizona":       "us-phoenix",
+	"usgovtexas":         "us-texas",
+	"canadacentral":      "ca-toronto",
+	"canadaeast":         "ca-quebec",
+	"mexicocentral":      "mx-queretaro",
+	"brazilsouth":        "br-sao-paulo",
+	"brazilsoutheast":    "br-rio-de-janeiro",
+	"chilecentral":       "cl-santiago",
+	"northeurope":        "ie-dublin",
+	"westeurope":         "nl-amsterdam",
+	"uksouth":            "gb-london",
+	"ukwest":             "gb-cardiff",
+	"francecentral":      "fr-paris",
+	"francesouth":        "fr-marseille",
+	"germanywestcentral": "de-frankfurt",
+	"germanynorth":       "de-berlin",
+	"switzerlandnorth":   "ch-zurich",
+	"switzerlandwest":    "ch-geneva",
+	"italynorth":         "it-milan",
+	"spaincentral":       "es-madrid",
+	"swedencentral":      "se-gavle",
+	"norwayeast":         "no-oslo",
+	"norwaywest":         "no-stavanger",
+	"polandcentral":      "pl-warsaw",
+	"austriaeast":        "at-vienna",
+	"southafricanorth":   "za-johannesburg",
+	"southafricawest":    "za-cape-town",
+	"uaenorth":           "ae-dubai",
+	"uaecentral":         "ae-abu-dhabi",
+	"qatarcentral":       "qa-doha",
+	"israelcentral":      "il-tel-aviv",
+	"centralindia":       "in-pune",
+	"southindia":         "in-chennai",
+	"westindia":          "in-mumbai",
+	"eastasia":           "hk-hong-kong",
+	"southeastasia":      "sg-singapore",
+	"indonesiacentral":   "id-jakarta",
+	"malaysiawest":       "my-kuala-lumpur",
+	"japaneast":          "jp-tokyo",
+	"japanwest":          "jp-osaka",
+	"koreacentral":       "kr-seoul",
+	"koreasouth":         "kr-busan",
+	"australiaeast":      "au-sydney",
+	"australiasoutheast": "au-melbourne",
+	"australiacentral":   "au-canberra",
+	"australiacentral2":  "au-canberra",
+	"newzealandnorth":    "nz-auckland",
+	"chinaeast":          "cn-shanghai",
+	"chinaeast2":         "cn-shanghai",
+	"chinaeast3":         "cn-shanghai",
+	"chinanorth":         "cn-beijing",
+	"chinanorth2":        "cn-beijing",
+	"chinanorth3":        "cn-beijing",
+}
+
+// gcpRegions maps GCP regions to a metro.
+var gcpRegions = map[string]string{
+	"us-central1":             "us-iowa",
+	"us-east1":                "us-south-carolina",
+	"us-east4":                "us-virginia",
+	"us-east5":                "us-ohio",
+	"us-south1":               "us-dallas",
+	"us-west1":                "us-oregon",
+	"us-west2":                "us-los-angeles",
+	"us-west3":                "us-salt-lake-city",
+	"us-west4":                "us-las-vegas",
+	"northamerica-northeast1": "ca-montreal",
+	"northamerica-northeast2": "ca-toronto",
+	"northamerica-south1":     "mx-queretaro",
+	"southamerica-east1":      "br-sao-paulo",
+	"southamerica-west1":      "cl-santiago",
+	"europe-west1":            "be-brussels",
+	"europe-west2":            "gb-london",
+	"europe-west3":            "de-frankfurt",
+	"europe-west4":            "nl-amsterdam",
+	"europe-west6":            "ch-zurich",
+	"europe-west8":            "it-milan",
+	"europe-west9":            "fr-paris",
+	"europe-west10":           "de-berlin",
+	"europe-west12":           "it-turin",
+	"europe-north1":           "fi-hamina",
+	"europe-north2":           "se-stockholm",
+	"europe-central2":         "pl-warsaw",
+	"europe-southwest1":       "es-madrid",
+	"me-west1":                "il-tel-aviv",
+	"me-central1":             "qa-doha",
+	"me-central2":             "sa-dammam",
+	"africa-south1":           "za-johannesburg",
+	"asia-east1":              "tw-changhua",
+	"asia-east2":              "hk-hong-kong",
+	"asia-northeast1":         "jp-tokyo",
+	"asia-northeast2":         "jp-osaka",
+	"asia-northeast3":         "kr-seoul",
+	"asia-south1":             "in-mumbai",
+	"asia-south2":             "in-delhi",
+	"asia-southeast1":         "sg-singapore",
+	"asia-southeast2":         "id-jakarta",
+	"australia-southeast1":    "au-sydney",
+	"australia-southeast2":    "au-melbourne",
+}
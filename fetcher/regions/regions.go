@@ -0,0 +1,147 @@
+// Package regions is the cross-provider region catalogue. It maps the region
+// codes of AWS, Azure and GCP onto a shared set of metro areas, so regions in
+// the same place (e.g. AWS eu-central-1, Azure germanywestcentral and GCP
+// europe-west3 in Frankfurt) get the same location key.
+package regions
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Zone types of a region.
+const (
+	ZoneRegion     = "region"
+	ZoneLocal      = "local_zone"
+	ZoneWavelength = "wavelength_zone"
+	ZoneGovCloud   = "govcloud"
+	ZoneChina      = "china"
+)
+
+// Info is the catalogue entry of a region.
+type Info struct {
+	DisplayName string
+	Country     string // ISO 3166-1 alpha-2 code
+	Continent   string
+	Latitude    *float64 // Approximate, the centre of the metro area
+	Longitude   *float64
+	ZoneType    string
+	LocationKey string // Metro key shared across providers, e.g. "de-frankfurt"
+}
+
+// Columns returns the region columns of the entry, for use with gorm's Updates.
+func (i Info) Columns() map[string]interface{} {
+	return map[string]interface{}{
+		"display_name": i.DisplayName,
+		"country":      i.Country,
+		"continent":    i.Continent,
+		"latitude":     i.Latitude,
+		"longitude":    i.Longitude,
+		"zone_type":    i.ZoneType,
+		"location_key": i.LocationKey,
+	}
+}
+
+// awsLocationTypes maps the AWS "locationType" attribute to a zone type.
+var awsLocationTypes = map[string]string{
+	"AWS Region":          ZoneRegion,
+	"AWS Local Zone":      ZoneLocal,
+	"AWS Wavelength Zone": ZoneWavelength,
+}
+
+// awsZonePattern matches the metro part of Local Zone and Wavelength Zone
+// codes, e.g. "lax" in "us-west-2-lax-1" or "bos" in "us-east-1-wl1-bos-wlz-1".
+var awsZonePattern = regexp.MustCompile(`^[a-z]{2}-[a-z]+-\d-(?:wl\d-)?([a-z]{3})-`)
+
+// AWS returns the catalogue entry of an AWS region. location and
+// locationType are the product attributes of the same name, e.g.
+// "EU (Frankfurt)" and "AWS Region"; either may be empty, in which case the
+// entry is derived from the region code alone.
+func AWS(regionCode, location, locationType string) Info {
+	entry, known := awsRegions[regionCode]
+	metroKey := entry.metro
+	if !known {
+		if m := awsZonePattern.FindStringSubmatch(regionCode); m != nil {
+			metroKey = awsZoneMetros[m[1]]
+		}
+	}
+
+	info := newInfo(metroKey)
+	info.DisplayName = firstNonEmpty(location, entry.name, info.DisplayName, regionCode)
+
+	switch {
+	case strings.HasPrefix(regionCode, "us-gov-"):
+		info.ZoneType = ZoneGovCloud
+	case strings.HasPrefix(regionCode, "cn-"):
+		info.ZoneType = ZoneChina
+	case awsLocationTypes[locationType] != "":
+		info.ZoneType = awsLocationTypes[locationType]
+	case strings.Contains(regionCode, "-wl"):
+		info.ZoneType = ZoneWavelength
+	case !known && awsZonePattern.MatchString(regionCode):
+		info.ZoneType = ZoneLocal
+	default:
+		info.ZoneType = ZoneRegion
+	}
+	return info
+}
+
+// Azure returns the catalogue entry of an Azure region by its ARM name, e.g.
+// "germanywestcentral". displayName and the coordinates come from the ARM
+// locations API and take precedence over the catalogue when set.
+func Azure(name, displayName string, latitude, longitude *float64) Info {
+	info := newInfo(azureRegions[name])
+	info.DisplayName = firstNonEmpty(displayName, info.DisplayName, name)
+	if latitude != nil && longitude != nil {
+		info.Latitude, info.Longitude = latitude, longitude
+	}
+
+	switch {
+	case strings.HasPrefix(name, "usgov") || strings.HasPrefix(name, "usdod"):
+		info.ZoneType = ZoneGovCloud
+	case strings.HasPrefix(name, "china"):
+		info.ZoneType = ZoneChina
+	default:
+		info.ZoneType = ZoneRegion
+	}
+	return info
+}
+
+// GCP returns the catalogue entry of a GCP region, e.g. "europe-west3".
+// description is the region description of the Compute API, used as the
+// display name when it says more than the name itself.
+func GCP(name, description string) Info {
+	info := newInfo(gcpRegions[name])
+	if description != name {
+		info.DisplayName = firstNonEmpty(description, info.DisplayName)
+	}
+	info.DisplayName = firstNonEmpty(info.DisplayName, name)
+	info.ZoneType = ZoneRegion
+	return info
+}
+
+// newInfo fills the location fields of an entry from its metro.
+func newInfo(metroKey string) Info {
+	m, ok := metros[metroKey]
+	if !ok {
+		return Info{}
+	}
+	lat, lon := m.lat, m.lon
+	return Info{
+		DisplayName: m.city,
+		Country:     m.country,
+		Continent:   m.continent,
+		Latitude:    &lat,
+		Longitude:   &lon,
+		LocationKey: metroKey,
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+// Package schema is the canonical data model shared by all providers. The
+// AWS, Azure and GCP fetchers write their providers, regions, SKUs, prices
+// and terms through these types, so the tables look the same whichever
+// provider ran first and can be queried across clouds.
+package schema
+
+import (
+	"time"
+)
+
+type Provider struct {
+	ProviderID   uint      `gorm:"primaryKey"`
+	ProviderName string    `gorm:"size:50;not null;unique"`
+	CreatedDate  time.Time `gorm:"default:current_timestamp"`
+	ModifiedDate time.Time `gorm:"default:current_timestamp"`
+	DisableFlag  bool      `gorm:"default:false"`
+}
+
+func (Provider) TableName() string {
+	return "providers"
+}
+
+// Region is a region of a provider, identified by (provider_id, region_code).
+// RegionCode is the provider's API name ("eu-central-1", "germanywestcentral",
+// "europe-west3"), RegionName its location as the provider labels it.
+type Region struct {
+	RegionID     uint     `gorm:"primaryKey"`
+	ProviderID   uint     `gorm:"not null;uniqueIndex:idx_regions_identity,priority:1"`
+	RegionCode   string   `gorm:"uniqueIndex:idx_regions_identity,priority:2"`
+	RegionName   string   `gorm:"column:region_name"`
+	DisplayName  string   `gorm:"size:100"`
+	Country      string   `gorm:"size:2"` // ISO 3166-1 alpha-2 code
+	Continent    string   `gorm:"size:20"`
+	Latitude     *float64 // Approximate, see the regions package
+	Longitude    *float64
+	ZoneType     string    `gorm:"size:20"`       // "region", "local_zone", "wavelength_zone", "govcloud" or "china"
+	LocationKey  string    `gorm:"size:50;index"` // Metro shared across providers, e.g. "de-frankfurt"
+	CreatedDate  time.Time `gorm:"default:current_timestamp"`
+	ModifiedDate time.Time `gorm:"default:current_timestamp"`
+	DisableFlag  bool      `gorm:"default:false"`
+}
+
+func (Region) TableName() string {
+	return "regions"
+}
+
+// SKU is a purchasable product of a provider in a region, identified by
+// (provider_id, region_id, sku_code). The raw attribute text is kept next to
+// the typed columns parsed from it.
+type SKU struct {
+	ID                  uint   `gorm:"primaryKey"`
+	RegionID            uint   `gorm:"not null;uniqueIndex:idx_skus_identity,priority:2"`
+	ProviderID          uint   `gorm:"not null;uniqueIndex:idx_skus_identity,priority:1"`
+	ServiceID           uint   `gorm:"index"` // AWS offer the SKU was read from, 0 for other providers
+	RegionCode          string `gorm:"not null"`
+	SKUCode             string `gorm:"uniqueIndex:idx_skus_identity,priority:3;index"` // Unique per provider and region
+	ArmSkuName          string `gorm:"column:arm_sku_name"`
+	InstanceSKU         string
+	ProductFamily       string
+	VCPU                int
+	CpuArchitecture     string
+	InstanceType        string   `gorm:"column:instance_type"`
+	Storage             string   // Raw storage, e.g. "2 x 900 NVMe SSD"
+	EBSOnly             *bool    `gorm:"column:ebs_only"`       // No local instance storage, NULL when unknown
+	DiskCount           *int     `gorm:"column:disk_count"`     // Number of local disks
+	DiskSizeGB          *float64 `gorm:"column:disk_size_gb"`   // Size of each local disk in decimal GB (10^9 bytes)
+	TotalLocalGB        *float64 `gorm:"column:total_local_gb"` // Total local instance storage in decimal GB
+	StorageMediaType    string   `gorm:"size:20"`               // "NVMe SSD", "SSD" or "HDD"
+	Network             string   // Raw network performance, e.g. "Up to 10 Gigabit"
+	NetworkBaselineMbps *float64 `gorm:"column:network_baseline_mbps"` // NULL when only a burst or no figure is published
+	NetworkBurstMbps    *float64 `gorm:"column:network_burst_mbps"`
+	OperatingSystem     string
+	Type                string
+	Memory              string    // Raw memory, e.g. "16 GiB"
+	MemoryGiB           *float64  `gorm:"column:memory_gib"`
+	PhysicalProcessor   string    `gorm:"column:physical_processor"`
+	MaxThroughput       string    `gorm:"column:max_throughput"`
+	EnhancedNetworking  string    `gorm:"column:enhanced_networking"`
+	GPU                 string    `gorm:"column:gpu"`
+	MaxIOPS             string    `gorm:"column:max_iops"`
+	ACUs                *int      `gorm:"column:acus"`                // Azure Compute Units
+	MaxDataDiskCount    *int      `gorm:"column:max_data_disk_count"` // Azure data disks that can be attached
+	PremiumIO           *bool     `gorm:"column:premium_io"`          // Azure premium SSD support
+	HyperVGenerations   string    `gorm:"size:20"`                    // Azure VM generations, e.g. "V1,V2"
+	Capabilities        *string   `gorm:"type:jsonb"`                 // Every capability of the Azure resource SKU
+	CreatedDate         time.Time `gorm:"default:current_timestamp"`
+	ModifiedDate        time.Time `gorm:"default:current_timestamp"`
+	DisableFlag         bool      `gorm:"default:false"`
+}
+
+func (SKU) TableName() string {
+	return "skus"
+}
+
+// Price is one rate of a SKU. PricePerUnit is an exact decimal, kept as a
+// string in Go to avoid float rounding.
+type Price struct {
+	PriceID              uint     `gorm:"primaryKey;autoIncrement"`
+	SKU_ID               uint     `gorm:"not null"`
+	TermType             string   `gorm:"type:varchar(50);index"` // "OnDemand", "Reserved", "Spot", "LowPriority" or "DevTest"
+	RateCode             string   `gorm:"type:varchar(255)"`
+	EffectiveDate        string   `gorm:"type:varchar(255)"`
+	Unit                 string   `gorm:"type:varchar(50)"`
+	Description          string   `gorm:"type:varchar(255)"`
+	PricePerUnit         string   `gorm:"type:numeric(20,10)"`
+	Currency             string   `gorm:"type:varchar(3);index"` // ISO currency code of PricePerUnit, e.g. USD or CNY
+	BeginRange           float64  `gorm:"default:0"`             // Lower usage bound of the tier (inclusive)
+	EndRange             *float64 // Upper usage bound of the tier (exclusive), NULL when unbounded
+	AppliesTo            string   `gorm:"type:text"` // Comma separated rate codes the price applies to
+	PriceType            string   `gorm:"size:50"`   // Source price type, e.g. Azure "Consumption", "Reservation" or "DevTestConsumption"
+	MeterID              string   `gorm:"size:64"`   // Azure meter the price is billed on
+	MeterName            string
+	ProductName          string
+	SkuName              string    // Azure skuName, e.g. "D2s v3 Spot"
+	IsSpot               bool      `gorm:"default:false"`
+	IsLowPriority        bool      `gorm:"default:false"`
+	IsPrimaryMeterRegion *bool     // Azure: whether the region is the primary billing region of the meter
+	CreatedDate          time.Time `gorm:"default:current_timestamp"`
+	ModifiedDate         time.Time `gorm:"default:current_timestamp"`
+	DisableFlag          bool      `gorm:"default:false"`
+}
+
+func (Price) TableName() string {
+	return "prices"
+}
+
+// Term holds the commitment of a reserved price.
+type Term struct {
+	OfferTermID         int    `gorm:"primaryKey;autoIncrement"`
+	SKU_ID              uint   `gorm:"not null"`
+	PriceID             uint   `gorm:"not null"`
+	LeaseContractLength string `gorm:"size:255"` // Raw length, e.g. "1yr" or "3 Years"
+	LeaseContractYears  *float64
+	PurchaseOption      string    `gorm:"size:255"`
+	OfferingClass       string    `gorm:"size:255"`
+	CreatedDate         time.Time `gorm:"default:current_timestamp"`
+	ModifiedDate        time.Time `gorm:"default:current_timestamp"`
+	DisableFlag         bool      `gorm:"default:false"`
+}
+
+func (Term) TableName() string {
+	return "terms"
+}
+
+// SavingPlan is the rate of a savings plan for one discounted SKU: an AWS
+// Savings Plan rate, or the savings plan rate of an Azure pay-as-you-go
+// price. Both providers store compute plans as "ComputeSavingsPlans", so
+// they can be compared.
+type SavingPlan struct {
+	ID                     uint `gorm:"primaryKey"`
+	DiscountedSku          string
+	DiscountedSkuID        *uint `gorm:"index"` // On-demand SKU the rate discounts, NULL when it is not ingested
+	Sku                    string
+	PlanType               string // "ComputeSavingsPlans" or "EC2InstanceSavingsPlans"
+	PurchaseOption         string // "All Upfront", "Partial Upfront" or "No Upfront"
+	PurchaseTerm           string // "1yr" or "3yr"
+	InstanceFamily         string // Instance family an EC2 Instance Savings Plan is bound to
+	UsageType              string
+	Description            string
+	EffectiveDate          string
+	LeaseContractLength    int
+	LeaseContractUnit      string
+	DiscountedRate         string
+	Currency               string `gorm:"type:varchar(3)"`
+	RateCode               string `gorm:"uniqueIndex:idx_saving_plans_region_rate_code,priority:2,where:rate_code LIKE '%.SavingsPlan.%'"`
+	RegionID               uint   `gorm:"not null;uniqueIndex:idx_saving_plans_region_rate_code,priority:1;constraint:OnDelete:CASCADE;"` // Foreign key with cascade delete
+	RegionCode             string `gorm:"not null"`
+	ProviderID             uint   `gorm:"not null;constraint:OnDelete:CASCADE;"` // Foreign key to providers
+	DiscountedInstanceType string `gorm:"not null"`
+	DiscountedUsageType    string
+	DiscountedOperation    string
+	DiscountedServiceCode  string
+	DiscountedRegionCode   string
+	Unit                   string    `gorm:"not null"`
+	CreatedDate            time.Time `gorm:"default:current_timestamp"`
+	ModifiedDate           time.Time `gorm:"default:current_timestamp"`
+	DisableFlag            bool      `gorm:"default:false"`
+}
+
+func (SavingPlan) TableName() string {
+	return "saving_plans"
+}
+
+// PriceHistory is one effective-dated version of a price (SCD type 2). It is
+// valid from ValidFrom up to, but not including, ValidTo; the current
+// version has ValidTo NULL. The SKU and region are copied by code rather
+// than referenced, since main_db's skus and prices are replaced on every
+// promotion while the history is kept.
+type PriceHistory struct {
+	ID                  uint   `gorm:"primaryKey"`
+	ProviderName        string `gorm:"size:50;not null"`
+	RegionCode          string `gorm:"not null"`
+	SKUCode             string `gorm:"not null"`
+	InstanceType        string
+	OperatingSystem     string
+	ProductFamily       string
+	TermType            string `gorm:"type:varchar(50)"`
+	RateCode            string `gorm:"type:varchar(255)"`
+	LeaseContractLength string `gorm:"size:255"`
+	PurchaseOption      string `gorm:"size:255"`
+	OfferingClass       string `gorm:"size:255"`
+	Unit                string `gorm:"type:varchar(50)"`
+	Currency            string `gorm:"type:varchar(3)"`
+	BeginRange          float64
+	EndRange            *float64
+	PricePerUnit        *string   `gorm:"type:numeric(20,10)"` // NULL when the source price was blank
+	Description         string    `gorm:"type:varchar(255)"`
+	EffectiveDate       string    `gorm:"type:varchar(255)"` // Effective date published by the provider
+	ValidFrom           time.Time `gorm:"not null"`
+	ValidTo             *time.Time
+}
+
+func (PriceHistory) TableName() string {
+	return "price_history"
+}
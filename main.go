@@ -70,7 +70,7 @@ func runTask() {
 }
 
 func main() {
-	// Initialize the logger and clear the logfile before appending new logs.
+	// Initialize the logger, appending to the existing logfile.
 	logger, err := config.InitializeLogger()
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
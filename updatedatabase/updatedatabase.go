@@ -1,9 +1,13 @@
 package updatedatabase
 
 import (
+	"cco-package/fetcher/config"
+	"cco-package/fetcher/migrations"
+	"cco-package/fetcher/pricing"
 	"fmt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"time"
 )
 
 var mainDb *gorm.DB
@@ -24,17 +28,17 @@ var tables = []string{
 func connectToDatabases() error {
 	var err error
 
-	mainDb, err = gorm.Open(postgres.Open("host=localhost user=postgres password=passworddbname=main_db port=5432 sslmode=disable"), &gorm.Config{})
+	mainDb, err = gorm.Open(postgres.Open(config.MainDbConnStr), &gorm.Config{})
 	if err != nil {
 		return fmt.Errorf("failed to connect to main DB: %w", err)
 	}
 
-	tempDb, err = gorm.Open(postgres.Open("host=localhost user=postgres password=password dbname=temp_db port=5432 sslmode=disable"), &gorm.Config{})
+	tempDb, err = gorm.Open(postgres.Open(config.DbConnStr), &gorm.Config{})
 	if err != nil {
 		return fmt.Errorf("failed to connect to temp DB: %w", err)
 	}
 
-	backupDb, err = gorm.Open(postgres.Open("host=localhost user=postgres password=password dbname=backup_db port=5432 sslmode=disable"), &gorm.Config{})
+	backupDb, err = gorm.Open(postgres.Open(config.BackupDbConnStr), &gorm.Config{})
 	if err != nil {
 		return fmt.Errorf("failed to connect to backup DB: %w", err)
 	}
@@ -48,6 +52,10 @@ func Updatedatabase() error {
 		return err
 	}
 
+	if err := checkSchemaVersions(); err != nil {
+		return err
+	}
+
 	var count int64
 	if err := mainDb.Table("providers").Count(&count).Error; err != nil {
 		return fmt.Errorf("failed to check if main_db has data: %w", err)
@@ -72,16 +80,54 @@ func Updatedatabase() error {
 		return fmt.Errorf("failed to insert temp_db data into main_db: %w", err)
 	}
 
-	// Empty temp_db
-	fmt.Println("Emptying temp_db...")
-	if err := emptyDatabase(tempDb); err != nil {
-		return fmt.Errorf("failed to empty temp_db: %w", err)
+	// Keep the prices that changed in price_history, which survives the
+	// truncation of main_db and the backup rotation.
+	fmt.Println("Recording price history in main_db...")
+	opened, closed, err := pricing.RecordHistory(mainDb, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record price history: %w", err)
 	}
+	fmt.Printf("Price history: %d new or changed prices, %d closed\n", opened, closed)
+
+	// temp_db is kept as is: the fetcher refreshes it incrementally and skips
+	// regions whose offer version was already ingested.
 
 	fmt.Println("Database migration completed successfully!")
 	return nil
 }
 
+// checkSchemaVersions refuses the promotion unless temp, main and backup
+// databases are at the same schema version, since the transfer copies rows
+// column by column. Run cmd/migrate to bring them to the same version.
+func checkSchemaVersions() error {
+	databases := []struct {
+		name string
+		db   *gorm.DB
+	}{
+		{"temp_db", tempDb},
+		{"main_db", mainDb},
+		{"backup_db", backupDb},
+	}
+
+	versions := make(map[string]int, len(databases))
+	for _, database := range databases {
+		version, err := migrations.Version(database.db)
+		if err != nil {
+			return fmt.Errorf("failed to read schema version of %s: %w", database.name, err)
+		}
+		versions[database.name] = version
+	}
+
+	tempVersion := versions["temp_db"]
+	for _, database := range databases[1:] {
+		if versions[database.name] != tempVersion {
+			return fmt.Errorf("schema versions differ (temp_db %d, main_db %d, backup_db %d); run cmd/migrate before promoting",
+				tempVersion, versions["main_db"], versions["backup_db"])
+		}
+	}
+	return nil
+}
+
 // Transfers data from sourceDb to targetDb
 func transferData(sourceDb, targetDb *gorm.DB) error {
 	const batchSize = 1000